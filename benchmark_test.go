@@ -0,0 +1,114 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// Run with: go test -run XXX -bench . -benchtime 3x
+// The time per task should stay roughly constant as the number of tasks grows.
+var benchmarkSizes = []int { 1000, 10000, 100000, 1000000 }
+
+func generateTasks(count int) string {
+	var builder strings.Builder
+
+	for i := 0; i < count; i++ {
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(&builder, "(A) 2020-08-01 task %d +project @context due:2020-08-%02d\n", i, i % 28 + 1)
+		case 1:
+			fmt.Fprintf(&builder, "x 2020-08-02 2020-08-01 completed task %d +project id:%d\n", i, i)
+		case 2:
+			fmt.Fprintf(&builder, "plain task number %d with a longer description and dep:%d\n", i, i - 1)
+		case 3:
+			fmt.Fprintf(&builder, "# comment %d\n", i)
+		}
+	}
+
+	return builder.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		contents := generateTasks(size)
+
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(contents)))
+
+			for i := 0; i < b.N; i++ {
+				if _, err := todo.ReadTasks(strings.NewReader(contents)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		contents := generateTasks(size)
+		doc := todo.ParseDocument(contents)
+
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(contents)))
+
+			for i := 0; i < b.N; i++ {
+				if err := doc.RenderTo(ioutil.Discard, doc.Tasks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriteTasks(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		tasks := todo.ParseAll(generateTasks(size))
+
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := todo.WriteTasks(ioutil.Discard, tasks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListTasks(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		tasks := todo.ParseAll(generateTasks(size))
+
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				listTasks(tasks)
+			}
+		})
+	}
+}
+
+func TestStreamingRoundTrip(t *testing.T) {
+	contents := generateTasks(100)
+
+	doc, err := todo.ReadDocument(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("Unable to read document: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := doc.RenderTo(&out, doc.Tasks); err != nil {
+		t.Fatalf("Unable to render document: %s", err)
+	}
+
+	if out.String() != contents {
+		t.Errorf("Streaming round trip changed the contents")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// Optional settings loaded from a JSON file. Every setting has a sensible default if the file doesn't exist.
+type Config struct {
+	Escalation []todo.EscalationRule `json:"escalation"`		// Rules applied by reprioritize
+	HooksDir   string                `json:"hooks_dir"`		// Directory containing pre and post hooks, defaults to ~/.config/todotogo/hooks
+	NotesDir   string                `json:"notes_dir"`		// Directory containing notes linked to tasks, defaults to notes next to the task file
+	Views      map[string]View       `json:"views"`			// Named views, run with view NAME or just NAME
+	Workflow   *todo.Workflow        `json:"workflow"`		// States of the status: key, defaults to todo.DefaultWorkflow
+	Urgency    todo.UrgencyCoefficients `json:"urgency"`	// Coefficients of the urgency score, unset coefficients keep their default
+}
+
+var config Config
+
+// workflow returns the configured workflow or the default one
+func workflow() todo.Workflow {
+	if config.Workflow != nil {
+		return *config.Workflow
+	}
+
+	return todo.DefaultWorkflow
+}
+
+// defaultConfigFilename returns ~/.config/todotogo/config.json (or the platform equivalent)
+func defaultConfigFilename() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "todotogo", "config.json")
+}
+
+func loadConfig(filename string) Config {
+	loaded := Config{ Urgency: todo.DefaultUrgency }
+
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		// A missing config file is fine, an unreadable one is not
+		if !os.IsNotExist(err) {
+			log.Fatalf("Unable to open config %s: %s", filename, err)
+		}
+
+		return loaded
+	}
+
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		log.Fatalf("Unable to parse config %s: %s", filename, err)
+	}
+
+	// A bad priority would otherwise only show up as a broken task line after reprioritize
+	for i, rule := range loaded.Escalation {
+		if err := rule.Validate(); err != nil {
+			log.Fatalf("Escalation rule %d in %s has an %s", i + 1, filename, err)
+		}
+	}
+
+	return loaded
+}
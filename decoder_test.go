@@ -0,0 +1,98 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestDecoder(t *testing.T) {
+	dec := todo.NewDecoder(strings.NewReader("# header\r\n\r\nfirst\r\n(B) second due:2020-08-01\r\nthird"))
+
+	expected := []string { "first", "(B) second due:2020-08-01", "third" }
+	lines := []int { 3, 4, 5 }
+
+	for i := 0; ; i++ {
+		task, err := dec.Next()
+		if err == io.EOF {
+			if i != len(expected) {
+				t.Errorf("Expected %d tasks but got %d", len(expected), i)
+			}
+			break
+		} else if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if task.String() != expected[i] {
+			t.Errorf(getMessage(expected[i], "decoder", expected[i], task))
+		}
+
+		if dec.Line() != lines[i] {
+			t.Errorf(getMessage(expected[i], "line number", lines[i], dec.Line()))
+		}
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	invalid := []string {
+		"(a) lowercase priority",
+		"2020-13-45 invalid creation date",
+		"2020-08-02 2020-08-01 completion date without x",
+		"task due:2020-02-30",
+		"x 2020-08-01",
+	}
+
+	for _, line := range invalid {
+		dec := todo.NewDecoder(strings.NewReader("valid task\n" + line + "\n"))
+		dec.Strict()
+
+		if _, err := dec.Next(); err != nil {
+			t.Errorf("Unexpected error for valid task: %s", err)
+		}
+
+		_, err := dec.Next()
+		if syntax, ok := err.(*todo.SyntaxError); !ok || syntax.Line != 2 {
+			t.Errorf(getMessage(line, "strict", "syntax error on line 2", err))
+		}
+	}
+}
+
+func TestDecoderDateLayouts(t *testing.T) {
+	dec := todo.NewDecoder(strings.NewReader("x 02.08.2020 01.08.2020 european dates due:05.08.2020\n"))
+	dec.DateLayouts("02.01.2006")
+
+	task, err := dec.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if task.DueDate != time.Date(2020, 8, 5, 0, 0, 0, 0, time.UTC) || task.CreationDate != time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC) {
+		t.Errorf(getMessage(task.String(), "date layouts", "x 2020-08-02 2020-08-01 european dates due:2020-08-05", task))
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var out bytes.Buffer
+	enc := todo.NewEncoder(&out)
+	enc.SetNewline("\r\n")
+
+	deleted := todo.ParseTask("deleted")
+	deleted.Deleted = true
+
+	for _, task := range []todo.Task { todo.ParseTask("(A) first"), deleted, todo.ParseTask("second") } {
+		if err := enc.Encode(task); err != nil {
+			t.Fatalf("Unable to encode: %s", err)
+		}
+	}
+
+	if out.String() != "(A) first\r\nsecond\r\n" {
+		t.Errorf(getMessage("encoder", "output", "(A) first\r\nsecond\r\n", out.String()))
+	}
+}
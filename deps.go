@@ -0,0 +1,60 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// printDependencies prints the tree of tasks each provided task depends on
+func printDependencies(input string, tasks Tasks) {
+	_, numbers := numbersToTasks(input, tasks, "")
+	if len(numbers) == 0 {
+		log.Fatalf("You must provide at least one task number")
+	}
+
+	for _, number := range numbers {
+		if cycle := todo.FindCycle(tasks, number); cycle != nil {
+			log.Printf("Warning: dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+
+		printDependencyTree(tasks, number, 0, map[int]bool{})
+	}
+}
+
+func printDependencyTree(tasks Tasks, number int, depth int, path map[int]bool) {
+	task := tasks[number]
+	indent := strings.Repeat("    ", depth)
+
+	status := ""
+	if task.Completed {
+		status = " [done]"
+	} else if task.Blocked {
+		status = " [blocked]"
+	}
+
+	if path[number] {
+		fmt.Printf("%s%03d %s [cycle]\n", indent, number + 1, task)
+		return
+	}
+
+	fmt.Printf("%s%03d %s%s\n", indent, number + 1, task, status)
+
+	path[number] = true
+	defer delete(path, number)
+
+	for _, dep := range task.Dependencies {
+		index := todo.FindByID(tasks, dep)
+		if index == -1 {
+			fmt.Printf("%s    %s (not found, possibly archived)\n", indent, dep)
+			continue
+		}
+
+		printDependencyTree(tasks, index, depth + 1, path)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestDependencies(t *testing.T) {
+	tasks := todo.ParseAll("x finished prerequisite id:1\nopen prerequisite id:2\nblocked task dep:1,2\nunblocked task dep:1 dep:archived\n")
+	todo.UpdateBlocked(tasks)
+
+	if len(tasks[2].Dependencies) != 2 || tasks[2].Dependencies[1] != "2" {
+		t.Errorf(getMessage(tasks[2].String(), "dependencies", "[1 2]", tasks[2].Dependencies))
+	}
+
+	blocked := []bool { false, false, true, false }
+	for i, task := range tasks {
+		if task.Blocked != blocked[i] {
+			t.Errorf(getMessage(task.String(), "blocked", blocked[i], task.Blocked))
+		}
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	tasks := todo.ParseAll("first id:a dep:c\nsecond id:b dep:a\nthird id:c dep:b\nstandalone id:d dep:a\n")
+
+	cycle := strings.Join(todo.FindCycle(tasks, 0), " ")
+	if cycle != "a c b a" {
+		t.Errorf(getMessage(tasks[0].String(), "cycle", "a c b a", cycle))
+	}
+
+	tasks = todo.ParseAll("first id:a\nsecond id:b dep:a\n")
+	if cycle := todo.FindCycle(tasks, 1); cycle != nil {
+		t.Errorf(getMessage(tasks[1].String(), "cycle", nil, cycle))
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	files := []string {
+		"",
+		"first\nsecond\n",
+		"first\nsecond",
+		"# Header\r\n\r\n(A)  2020-08-01 spaced  task\r\nx 2020-08-02 2020-08-01 done\r\n",
+		"\n\n# only comments and blank lines\n   \n",
+	}
+
+	for _, contents := range files {
+		if actual := todo.ParseDocument(contents).String(); actual != contents {
+			t.Errorf(getMessage(contents, "round trip", contents, actual))
+		}
+	}
+}
+
+func TestDocumentRender(t *testing.T) {
+	contents := "# Work\r\nfirst\r\n\r\n# Home\r\nsecond\r\nthird"
+	doc := todo.ParseDocument(contents)
+
+	if len(doc.Tasks) != 3 {
+		t.Fatalf("Expected 3 tasks but got %d", len(doc.Tasks))
+	}
+
+	tasks := append([]todo.Task{}, doc.Tasks...)
+	tasks[0].Completed = true
+	tasks[1].Deleted = true
+	tasks = append(tasks, todo.ParseTask("fourth"))
+
+	expected := "# Work\r\nx first\r\n\r\n# Home\r\nthird\r\nfourth"
+	if actual := doc.Render(tasks); actual != expected {
+		t.Errorf(getMessage(contents, "render", expected, actual))
+	}
+}
+
+func TestDocumentComments(t *testing.T) {
+	// Only "# " and a lone # start a comment, anything else is a task even if it starts with a hashtag
+	contents := "# Work\n#hashtag fix build\n#\n# Home\nsecond\n"
+	doc := todo.ParseDocument(contents)
+
+	if len(doc.Tasks) != 2 || doc.Tasks[0].String() != "#hashtag fix build" {
+		t.Fatalf("Unexpected tasks %v", doc.Tasks)
+	}
+
+	// Comments move along with the task they lead up to
+	tasks := []todo.Task{ doc.Tasks[1], doc.Tasks[0] }
+	expected := "#\n# Home\nsecond\n# Work\n#hashtag fix build\n"
+	if actual := doc.Render(tasks); actual != expected {
+		t.Errorf(getMessage(contents, "reordered render", expected, actual))
+	}
+
+	// Edited tasks keep the comments of the task they replaced
+	tasks = []todo.Task{ todo.ParseTask("#hashtag fixed build"), doc.Tasks[1] }
+	expected = "# Work\n#hashtag fixed build\n#\n# Home\nsecond\n"
+	if actual := doc.Render(tasks); actual != expected {
+		t.Errorf(getMessage(contents, "edited render", expected, actual))
+	}
+}
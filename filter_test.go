@@ -0,0 +1,53 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestParseFilter(t *testing.T) {
+	tasks := todo.ParseAll(`(A) write report +work @office due:2020-08-01
+(C) buy milk +home @store
+x (B) file taxes +home due:2020-04-15
+call mom @phone owner:me
+`)
+
+	cases := map[string]string {
+		"": "[0 1 2 3]",
+		"+home": "[1 2]",
+		"+home -is:done": "[1]",
+		"@office OR @phone": "[0 3]",
+		"pri>=B": "[0 2]",
+		"pri:C": "[1]",
+		"owner:me": "[3]",
+		"MILK": "[1]",
+		"/^x\\s/": "[2]",
+		"due<2020-08-01": "[2]",
+		"due<=2020-08-01": "[0 2]",
+		"(+work OR +home) NOT is:done": "[0 1]",
+		"due:2020-04-15": "[2]",
+	}
+
+	for query, expected := range cases {
+		filter, err := todo.ParseFilter(query)
+		if err != nil {
+			t.Errorf("Unable to parse %q: %s", query, err)
+			continue
+		}
+
+		if actual := fmt.Sprint(filter.Indexes(tasks)); actual != expected {
+			t.Errorf(getMessage(query, "filter", expected, actual))
+		}
+	}
+
+	for _, invalid := range []string { "(+work", "+work)", "/[/", "pri>=1" } {
+		if _, err := todo.ParseFilter(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestHelpOrder(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	printHelp()
+
+	// Commands are listed alphabetically, ignoring the brackets around abbreviations
+	var commands []string
+	for _, line := range strings.Split(output.String(), "\n")[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			commands = append(commands, strings.NewReplacer("[", "", "]", "").Replace(fields[0]))
+		}
+	}
+
+	if !sort.StringsAreSorted(commands) {
+		sorted := append([]string{}, commands...)
+		sort.Strings(sorted)
+		t.Errorf("Help is not sorted alphabetically.\nExpected: %v\nActual:   %v", sorted, commands)
+	}
+}
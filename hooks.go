@@ -0,0 +1,193 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Hooks:
+ * Executables named pre-COMMAND and post-COMMAND (i.e. pre-add, post-do, post-archive) in the hooks directory are run around every command which writes a task file.
+ * The pre hook runs right before the first write (with every file that write changes) and the command is aborted if it exits with a non-zero status.
+ * The post hook runs after the command finished.
+ * Both receive a JSON document with the affected tasks on stdin and the TODO_COMMAND, TODO_HOOK and TODO_FILE environment variables.
+ */
+
+// A single task which was added, removed or modified by the current command
+type hookTask struct {
+	File   string    `json:"file"`		// Task file this change was written to
+	Change string    `json:"change"`	// added, removed or modified
+	Number int       `json:"number"`	// Task number in the written file (0 for removed tasks)
+	Text   string    `json:"text"`
+	Task   todo.Task `json:"task"`
+}
+
+type hookPayload struct {
+	Command string     `json:"command"`
+	Hook    string     `json:"hook"`
+	File    string     `json:"file"`
+	Tasks   []hookTask `json:"tasks"`
+}
+
+var hooksEnabled bool
+var hookCommand string
+var hookPreRan bool
+var hookAffected []hookTask
+
+// Full names of all abbreviated commands so that only one hook has to be written per command
+var commandAliases = map[string]string {
+	"a": "add",
+	"ar": "archive",
+	"d": "do",
+	"e": "edit",
+	"f": "find",
+	"h": "help",
+	"l": "list",
+	"n": "note",
+	"q": "quick",
+	"r": "rm",
+	"s": "search",
+	"u": "undo",
+}
+
+func canonicalCommand(command string) string {
+	if full, ok := commandAliases[command]; ok {
+		return full
+	} else if command == "" {
+		return "quick"
+	}
+
+	return command
+}
+
+func hooksDir() string {
+	if config.HooksDir != "" {
+		return config.HooksDir
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "todotogo", "hooks")
+}
+
+// diffTasks returns every task which differs between the old and new contents of a file
+func diffTasks(filename string, old, new Tasks) []hookTask {
+	var changes []hookTask
+
+	previous := make(map[string]todo.Task)
+	for _, task := range old {
+		previous[task.Identity()] = task
+	}
+
+	number := 0
+	for _, task := range new {
+		if task.Deleted {
+			continue
+		}
+		number++
+
+		key := task.Identity()
+		before, existed := previous[key]
+		delete(previous, key)
+
+		if existed && before.String() == task.String() {
+			continue
+		}
+
+		change := "added"
+		if existed {
+			change = "modified"
+		}
+
+		changes = append(changes, hookTask{ File: filename, Change: change, Number: number, Text: task.String(), Task: task })
+	}
+
+	for _, task := range old {
+		if _, removed := previous[task.Identity()]; removed {
+			changes = append(changes, hookTask{ File: filename, Change: "removed", Text: task.String(), Task: task })
+		}
+	}
+
+	return changes
+}
+
+// runPreHook is called by the store before task files are written and aborts the writes if the pre hook fails
+func runPreHook(writes []todo.FileWrite) error {
+	if !hooksEnabled {
+		return nil
+	}
+
+	// Every file written by the operation is included so hooks see archive changes too
+	var changes []hookTask
+	for _, w := range writes {
+		changes = append(changes, diffTasks(w.Filename, loadTasks(w.Filename, false), w.Tasks)...)
+	}
+	hookAffected = append(hookAffected, changes...)
+
+	// Commands which write several times only run the pre hook once
+	if hookPreRan {
+		return nil
+	}
+	hookPreRan = true
+
+	if err := runHook("pre", changes); err != nil {
+		return fmt.Errorf("aborting %s: %w", hookCommand, err)
+	}
+
+	return nil
+}
+
+// runPostHook is called after the command finished if it wrote any files
+func runPostHook() {
+	if !hooksEnabled || !hookPreRan {
+		return
+	}
+
+	if err := runHook("post", hookAffected); err != nil {
+		log.Printf("Warning: %s", err)
+	}
+}
+
+func runHook(stage string, changes []hookTask) error {
+	name := stage + "-" + hookCommand
+	path := filepath.Join(hooksDir(), name)
+
+	// Missing and non executable hooks are silently skipped
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Mode() & 0111 == 0 {
+		return nil
+	}
+
+	if changes == nil {
+		changes = []hookTask{}
+	}
+
+	payload, err := json.Marshal(hookPayload{ Command: hookCommand, Hook: name, File: filename, Tasks: changes })
+	if err != nil {
+		return fmt.Errorf("unable to encode hook payload: %s", err)
+	}
+
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "TODO_COMMAND=" + hookCommand, "TODO_HOOK=" + name, "TODO_FILE=" + filename)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %s failed: %s", name, err)
+	}
+
+	return nil
+}
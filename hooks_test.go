@@ -0,0 +1,96 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func writeHook(t *testing.T, dir, name, script string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n" + script), 0755); err != nil {
+		t.Fatalf("Unable to write hook %s: %s", name, err)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Hook scripts need a POSIX shell")
+	}
+
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	payloadFile := filepath.Join(dir, "payload.json")
+	writeHook(t, dir, "pre-archive", "cat > '" + payloadFile + "'\n")
+	writeHook(t, dir, "pre-add", "exit 1\n")
+
+	saveGlobals(t)
+	config.HooksDir = dir
+	filename = filepath.Join(dir, "todo.txt")
+	hooksEnabled, hookCommand, hookPreRan, hookAffected = true, "archive", false, nil
+	openStore()
+
+	if err := ioutil.WriteFile(filename, []byte("x finished +work\nopen\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", filename, err)
+	}
+
+	tasks, _ := store.Load()
+	if _, err := store.Archive(tasks); err != nil {
+		t.Fatalf("Unable to archive: %s", err)
+	}
+
+	raw, err := ioutil.ReadFile(payloadFile)
+	if err != nil {
+		t.Fatalf("The pre-archive hook didn't run: %s", err)
+	}
+
+	var payload hookPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatalf("Unable to parse the hook payload %s: %s", raw, err)
+	}
+
+	// The archive and the main file are both part of the same write
+	changes := make(map[string]string)
+	for _, change := range payload.Tasks {
+		changes[filepath.Base(change.File) + " " + change.Change] = change.Text
+	}
+
+	expected := map[string]string {
+		"todo-done.txt added": "x finished +work",
+		"todo.txt removed": "x finished +work",
+	}
+
+	if payload.Command != "archive" || payload.Hook != "pre-archive" || len(changes) != len(expected) {
+		t.Errorf("Unexpected payload %s", raw)
+	}
+
+	for key, text := range expected {
+		if changes[key] != text {
+			t.Errorf(getMessage(text, key, text, changes[key]))
+		}
+	}
+
+	// A failing pre hook aborts the write
+	hookCommand, hookPreRan = "add", false
+
+	tasks, _ = store.Load()
+	tasks = append(tasks, todo.ParseTask("added"))
+	if err := store.Save(tasks); err == nil {
+		t.Errorf("Expected the failing pre-add hook to abort the write")
+	}
+
+	if contents, _ := ioutil.ReadFile(filename); string(contents) != "open\n" {
+		t.Errorf(getMessage("added", "contents after an aborted write", "open\n", string(contents)))
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// listCommand implements list [--archive] [--group-by status] [--sort KEYS] [QUERY]
+func listCommand(args []string, tasks Tasks) {
+	archive, groupBy, sortBy := false, "", ""
+
+	// Options are parsed by hand since a query can start with - (i.e. -is:done)
+	for len(args) > 0 {
+		if args[0] == "--archive" {
+			archive = true
+			args = args[1:]
+		} else if (args[0] == "--group-by" || args[0] == "--sort") && len(args) > 1 {
+			if args[0] == "--sort" {
+				sortBy = args[1]
+			} else {
+				groupBy = args[1]
+			}
+			args = args[2:]
+		} else {
+			break
+		}
+	}
+
+	filter := todo.And(contextFilter(), parseQuery(strings.Join(args, " ")))
+
+	if archive {
+		listArchive(filter)
+		return
+	}
+
+	if groupBy != "" {
+		if groupBy != "status" {
+			log.Fatalf("Tasks can only be grouped by status")
+		}
+		printKanban(tasks, filter)
+		return
+	}
+
+	numbers := filter.Indexes(tasks)
+	if err := todo.SortIndexes(tasks, numbers, sortBy, customSortKeys()...); err != nil {
+		log.Fatalf("Invalid sort: %s", err)
+	}
+
+	fmt.Println(listTasksDimmed(tasks, numbers))
+}
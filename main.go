@@ -26,9 +26,35 @@ import (
 	add/a		add new task
 	list/l		list current tasks
  	do/d		mark task X as done
- 	rm/r		delete task X
+ 	rm/r		move task X to FILENAME-trash.txt (or delete it with --permanent)
  	archive/ar	move all completed tasks to filename-done.txt
 	edit/e		save the description to a temp file, exec editor and save
+	merge		three way merge of base, ours and theirs by task identity
+	deps		print the dependency tree of a task and detect cycles
+	start/stop	track time spent on a task in FILENAME-time.txt and its spent: key
+	status		show the task currently being tracked
+	report		sum tracked time per task, project or context (--since, --by; timereport without flags)
+	stats		productivity statistics across the main file and archive
+	pri/depri	set or remove the priority of tasks
+	reprioritize	escalate priorities as due dates approach using rules from the config
+	hooks		pre-COMMAND and post-COMMAND executables run around every write
+	todo.sh compatibility: append, prepend, replace, listpri, listproj, listcon, listall, deduplicate, report and add-on actions
+	search/s	non-interactive literal or regex search with highlighted matches
+	note/n		create or open a Markdown note linked through the note: key
+	show		print tasks along with their notes
+	projects	project (or contexts) tree with open/done counts and next due date
+	rename-project	rename a project (or rename-context) across the main file and archive
+	context		persistent filter applied to quick, list and find
+	view		named views (filter, sort and format) from the config
+	mv/top/bottom/swap	reorder tasks in the file (the backlog rank, usable as the file sort key)
+	trash/restore	list, restore and purge removed tasks
+	unarchive	move archived tasks back (list --archive numbers the archive)
+	status:		todo, doing, waiting, done and cancelled (configurable) with set-status, wait and cancel
+	wait/waiting	delegate tasks with waiting: and followup: keys and report on them
+	next		most urgent tasks by a configurable urgency score (also the urgency sort key)
+	plan		time-boxed daily plan from est: durations and .ics meetings
+	review		interactive review of overdue, stale and past threshold tasks in a single write
+	snooze/reschedule	move due: and t: dates forward, counting snoozes in snoozed:
 	find/f - loads the contents in multiselect fzf OR an interactive prompt that searches for the given substring
 
 	With no argument, incomplete tasks from 1-6 days ago should be displayed along with tasks for the next 7 days up to X in each direction
@@ -41,6 +67,9 @@ import (
 
  var backup bool
  var filename string
+ var doneFilename string
+ var store todo.Store
+ var unlockStore func() error
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
@@ -48,13 +77,23 @@ func main() {
 	// Parse all flags
 	filenameFlag := flag.String("f", "todo.txt", "Input filename")
 	autoBackupFlag := flag.Bool("b", false, "Disables automatic backup. (dangerous!)")
+	configFlag := flag.String("c", defaultConfigFilename(), "Config filename")
+	hooksFlag := flag.Bool("hooks", false, "Run hooks even when automatic backup is disabled with -b")
 
 	flag.Parse()
 
 	filename = *filenameFlag
+	if !isFlagSet("f") {
+		filename, doneFilename = todoShFiles(filename)
+	}
 	backup = !(*autoBackupFlag)
+	config = loadConfig(*configFlag)
 	command := flag.Arg(0)		// optional command (add, rm, etc.)
 
+	// Scenarios without backups are meant to be quick and quiet, so they only run hooks when asked to
+	hooksEnabled = backup || *hooksFlag
+	hookCommand = canonicalCommand(command)
+
 	// Parse any extra arguments
 	args := flag.Args()
 	extra := ""
@@ -62,8 +101,23 @@ func main() {
 		extra = strings.Join(args[1:], " ")
 	}
 
+	// todo.sh add-on actions take precedence over built in commands, just like in todo.sh
+	if action := findAction(command); action != "" {
+		os.Exit(runAction(action, args))
+	}
+
+	// Merging operates on arbitrary files and is often run by git, so it doesn't need the current task list
+	if command == "merge" {
+		os.Exit(mergeCommand(args[1:]))
+	}
+
 	// Parse initial task list and save the current time
-	tasks := loadTasks(filename, true)
+	openStore()
+	tasks, err := store.Load()
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+	todo.UpdateBlocked(tasks)
 	n := time.Now()
 
 	if command == "help" || command == "h" {
@@ -74,6 +128,9 @@ func main() {
 		lower := n.AddDate(0, 0, -7)
 		upper := n.AddDate(0, 0, 7)
 
+		active := contextFilter()
+		markers := len(tasks)
+
 		// Tasks above this are due today
 		marker := fmt.Sprintf("+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+ due:%s", n.Format("2006-01-02"))
 		tasks = append(tasks, todo.ParseTask(marker))
@@ -87,10 +144,10 @@ func main() {
 		tmp := make(Tasks, len(tasks))
 		copy(tmp, tasks)
 
+		visible := todo.And(todo.Not(todo.Completed()), todo.Not(todo.Blocked()), todo.DueBetween(lower, upper))
+
 		for _, task := range todo.SortByDate(tmp) {
-			if time.Time.IsZero(task.DueDate) || task.Completed {
-				continue
-			} else if !(lower.Before(task.DueDate) && task.DueDate.Before(upper)) {
+			if !visible(task) {
 				continue
 			}
 
@@ -100,16 +157,47 @@ func main() {
 				log.Fatalf("Unable to find task with hash %s", task.Hash)
 			}
 
+			// The due date markers are always shown
+			if number < markers && !active(task) {
+				continue
+			}
+
 			fmt.Printf("%03d %s\n", number + 1, task)
 		}
 
+		printFollowups(tasks[:markers], active, time.Now())
+
 	} else if command == "list" || command == "l" {
-		fmt.Println(listTasks(tasks))
+		listCommand(args[1:], tasks)
+
+	} else if command == "snooze" {
+		snoozeCommand(args[1:], tasks)
+
+	} else if command == "reschedule" {
+		rescheduleCommand(args[1:], tasks)
+
+	} else if command == "review" {
+		reviewCommand(args[1:], tasks)
+
+	} else if command == "plan" {
+		planDay(args[1:], tasks)
+
+	} else if command == "next" {
+		printNext(args[1:], tasks)
+
+	} else if command == "note" || command == "n" {
+		editNote(extra, tasks)
+
+	} else if command == "show" {
+		showTasks(extra, tasks)
+
+	} else if command == "search" || command == "s" {
+		os.Exit(searchTasks(args[1:], tasks))
 
 	} else if command == "find" || command == "f" {
 		oneLine := ""
 		
-		sel := findTask(tasks)
+		sel := findTask(tasks, contextFilter())
 		for _, t := range sel {
 			fmt.Printf("%03d %s\n", t + 1, tasks[t])
 			oneLine += fmt.Sprintf("%d ", t + 1)
@@ -127,10 +215,10 @@ func main() {
 			log.Fatalf("Error: you must specify a task")
 		}
 
-		backupOriginal(backup, filename)
+		backupOriginal(backup)
 
 		tasks = append(tasks, task)
-		writeTasks(filename, tasks)
+		writeTasks(tasks)
 
 		log.Printf("Successfully added task %s", task)
 
@@ -141,34 +229,106 @@ func main() {
 		markTasks(extra, tasks, false)
 
 	} else if command == "rm" || command == "r" {
-		_, numbers := numbersToTasks(extra, tasks, "Removed the following tasks:")
+		removeTasks(args[1:], tasks)
 
-		for _, task := range numbers {
-			tasks[task].Deleted = true
-		}
+	} else if command == "unarchive" {
+		unarchiveTasks(args[1:], tasks)
 
-		writeTasks(filename, tasks)
+	} else if command == "set-status" {
+		setStatus(args[1:], tasks)
 
-	} else if command == "archive" || command == "ar" {
-		archiveName := strings.ReplaceAll(filename, ".txt", "-done.txt")
-		archived := loadTasks(archiveName, false)
-		var remaining Tasks
+	} else if command == "wait" {
+		waitTask(args[1:], tasks)
 
-		backupOriginal(backup, filename)
+	} else if command == "waiting" {
+		printWaitingReport(tasks, n)
 
-		log.Printf("Archived the following tasks:")
-		for _, task := range tasks {
-			if !task.Completed {
-				remaining = append(remaining, task)
-				continue
-			}
+	} else if command == "cancel" {
+		cancelTasks(extra, tasks)
 
-			archived = append(archived, task)
-			log.Printf("%s", task)
+	} else if command == "trash" {
+		trashCommand(args[1:], tasks)
+
+	} else if command == "restore" {
+		restoreTasks(extra, tasks)
+
+	} else if command == "deps" {
+		printDependencies(extra, tasks)
+
+	} else if command == "start" {
+		startTimer(extra, tasks)
+
+	} else if command == "stop" {
+		intervals := loadTimeLog()
+		backupOriginal(backup)
+
+		if !stopTimer(intervals, tasks, n) {
+			log.Fatalf("No task is being tracked")
 		}
 
-		writeTasks(archiveName, archived)
-		writeTasks(filename, remaining)
+		writeTasks(tasks)
+		writeTimeLog(intervals)
+
+	} else if command == "status" {
+		printTimerStatus(tasks)
+
+	} else if command == "timereport" || (command == "report" && len(args) > 1) {
+		// todo.sh's report takes no arguments, so report with flags is always the time report
+		printTimeReport(args[1:], tasks)
+
+	} else if command == "report" {
+		todoShReport(tasks)
+
+	} else if command == "append" || command == "app" {
+		appendTask(extra, tasks, false)
+
+	} else if command == "prepend" || command == "prep" {
+		appendTask(extra, tasks, true)
+
+	} else if command == "replace" {
+		replaceTask(extra, tasks)
+
+	} else if command == "listpri" || command == "lsp" {
+		listPriorities(extra, tasks)
+
+	} else if command == "listproj" || command == "lsprj" {
+		listTags(tasks, true)
+
+	} else if command == "projects" {
+		printTagSummary(tasks, true)
+
+	} else if command == "contexts" {
+		printTagSummary(tasks, false)
+
+	} else if command == "rename-project" {
+		renameTag(args[1:], tasks, true)
+
+	} else if command == "rename-context" {
+		renameTag(args[1:], tasks, false)
+
+	} else if command == "listcon" || command == "lsc" {
+		listTags(tasks, false)
+
+	} else if command == "listall" || command == "lsa" {
+		listAll(extra, tasks)
+
+	} else if command == "deduplicate" {
+		deduplicate(tasks)
+
+	} else if command == "pri" || command == "p" {
+		setPriority(args[1:], tasks, false)
+
+	} else if command == "depri" || command == "dp" {
+		setPriority(args[1:], tasks, true)
+
+	} else if command == "reprioritize" {
+		reprioritize(args[1:], tasks)
+
+	} else if command == "stats" {
+		printStats(args[1:], tasks)
+
+	} else if command == "archive" || command == "ar" {
+		archiveTasks(tasks)
 
 	} else if command == "edit" || command == "e" {
 		provided := strings.Fields(extra)
@@ -176,7 +336,7 @@ func main() {
 			log.Fatalf("You must provide at least one task number")
 		}
 
-		backupOriginal(backup, filename)
+		backupOriginal(backup)
 
 		for index, raw := range provided {
 			i, _ := strconv.ParseInt(raw, 10, 32)
@@ -192,25 +352,90 @@ func main() {
 			tasks[i] = todo.ParseTask(new)
 		}
 
-		writeTasks(filename, tasks)
+		writeTasks(tasks)
+
+	} else if command == "mv" || command == "top" || command == "bottom" || command == "swap" {
+		reorderTasks(command, args[1:], tasks)
+
+	} else if command == "context" {
+		contextCommand(args[1:])
+
+	} else if command == "view" {
+		if len(args) != 2 {
+			log.Fatalf("Usage: view NAME")
+		}
+		runView(args[1], tasks)
+
+	} else if _, ok := config.Views[command]; ok {
+		runView(command, tasks)
 
 	} else {
 		log.Printf("Unknown subcommand %s", command)
 		printHelp()
 	}
+
+	if unlockStore != nil {
+		unlockStore()
+	}
+
+	runPostHook()
 }
 
 func printHelp() {
 	log.Printf("Available commands:")
-	log.Printf("[a]dd      Adds new task")
-	log.Printf("[ar]chive  Moves all completed tasks to FILENAME-done.txt")
-	log.Printf("[d]o       Marks the task(s) as complete")
-	log.Printf("[e]dit     Interactively edit the provided task(s) in the default editor")
-	log.Printf("[f]ind     Interactively find task(s) with fzf")
-	log.Printf("[l]ist     Lists all tasks")
-	log.Printf("[q]uick    List tasks due in the previous and next seven days. Default action")
-	log.Printf("[r]m       Permanently deletes the provided task(s)")
-	log.Printf("[u]ndo     Marks the task(s) as incomplete")
+	log.Printf("[a]dd        Adds new task")
+	log.Printf("app[end]     Adds text to the end of the task")
+	log.Printf("[ar]chive    Moves all completed tasks to FILENAME-done.txt and cancelled tasks to FILENAME-cancelled.txt")
+	log.Printf("bottom       Moves the task to the end of the file")
+	log.Printf("cancel       Moves the task(s) to the cancelled status")
+	log.Printf("context      Shows the active context, set QUERY applies it to quick, list and find until clear")
+	log.Printf("contexts     Lists contexts with open/done counts and the next due date")
+	log.Printf("deduplicate  Removes duplicate tasks")
+	log.Printf("depri        Removes the priority of the provided task(s) (-q to select by query)")
+	log.Printf("deps         Prints the dependency tree of the provided task(s)")
+	log.Printf("[d]o         Marks the task(s) as complete")
+	log.Printf("[e]dit       Interactively edit the provided task(s) in the default editor")
+	log.Printf("[f]ind       Interactively find task(s) with fzf")
+	log.Printf("[l]ist       Lists all tasks or the tasks matching QUERY (i.e. +project @context pri>=B due<7d). Tasks with notes are marked with *. Options: --archive, --group-by status, --sort KEYS")
+	log.Printf("listall      Lists tasks in both the main file and the archive (lsa)")
+	log.Printf("listcon      Lists all contexts (lsc)")
+	log.Printf("listpri      Lists prioritized tasks, optionally limited to PRIORITIES such as A-C (lsp)")
+	log.Printf("listproj     Lists all projects (lsprj)")
+	log.Printf("merge        Three way merge of BASE OURS THEIRS (usable as a git merge driver)")
+	log.Printf("mv           Moves TASK to POSITION in the file, shifting the tasks in between")
+	log.Printf("next         Prints the N (default 5) most urgent tasks and why they are urgent")
+	log.Printf("[n]ote       Creates or opens the Markdown note linked to the task")
+	log.Printf("plan         Fills the day with est: tasks around meetings (-hours 6, -start 09:00, -ics FILE, -stamp)")
+	log.Printf("prep[end]    Adds text to the beginning of the task")
+	log.Printf("pri          Sets the priority of the provided task(s) (-q to select by query)")
+	log.Printf("projects     Lists projects as a tree with open/done counts and the next due date")
+	log.Printf("[q]uick      List tasks due in the previous and next seven days and tasks to follow up on. Default action")
+	log.Printf("rename-context Renames a context in the main file and archive")
+	log.Printf("rename-project Renames a project and its subprojects in the main file and archive")
+	log.Printf("replace      Replaces the task with new text")
+	log.Printf("report       Sums tracked time (--since 1w, --by task|project|context), without flags archives and appends open/done counts to report.txt (like todo.sh)")
+	log.Printf("reprioritize Raises priorities of tasks nearing their due date (-n for a dry run)")
+	log.Printf("reschedule   Moves open tasks matching QUERY (or --overdue) to DATE, i.e. reschedule --overdue to today (-n for a dry run)")
+	log.Printf("restore      Puts the provided trashed task(s) back in their original position")
+	log.Printf("review       Walks through overdue, stale (-days 30) and past threshold tasks and applies the decisions at once")
+	log.Printf("[r]m         Moves the provided task(s) to the trash (--permanent deletes them)")
+	log.Printf("[s]earch     Prints tasks matching PATTERN (-e regex, -i ignore case, -a include archive, -in FIELD)")
+	log.Printf("set-status   Moves the task(s) to STATUS, following the workflow from the config")
+	log.Printf("show         Prints the task(s) along with their notes")
+	log.Printf("snooze       Moves due: and t: of TASK(s) or QUERY by OFFSET (3d, 1w) or to a date (tomorrow, fri) (-n for a dry run)")
+	log.Printf("start        Starts tracking time spent on the provided task and moves it to doing")
+	log.Printf("stats        Shows productivity statistics for active and archived tasks (--json)")
+	log.Printf("status       Shows the task currently being tracked")
+	log.Printf("stop         Stops tracking time")
+	log.Printf("swap         Swaps the positions of two tasks in the file")
+	log.Printf("timereport   Sums all tracked time, the same as report with flags")
+	log.Printf("top          Moves the task to the beginning of the file")
+	log.Printf("trash        Lists trashed tasks, trash empty [--older-than 30d] purges them")
+	log.Printf("unarchive    Moves archived tasks selected by QUERY, ID or archive number (A3) back to the main file (--reopen)")
+	log.Printf("[u]ndo       Marks the task(s) as incomplete")
+	log.Printf("view         Runs a view from the config (views can also be run by name)")
+	log.Printf("wait         Moves the task to waiting for PERSON with an optional FOLLOWUP date (i.e. fri)")
+	log.Printf("waiting      Lists delegated tasks by person with the number of days each has been waiting")
 }
 
 func editTask(original string) string {
@@ -246,7 +471,7 @@ func editTask(original string) string {
 	return contents
 }
 
-func findTask(tasks Tasks) []int {
+func findTask(tasks Tasks, filter todo.Filter) []int {
 	// Create a temporary file to hold all tasks
 	file, tmpErr := ioutil.TempFile("/tmp", "task.")
 	if tmpErr != nil {
@@ -255,10 +480,13 @@ func findTask(tasks Tasks) []int {
 	tmp := file.Name()
 	defer os.Remove(tmp)
 
-	all := listTasks(tasks)
+	var all strings.Builder
+	for _, i := range filter.Indexes(tasks) {
+		fmt.Fprintf(&all, "%03d %s\n", i + 1, tasks[i])
+	}
 
 	// Write out the contents of the task
-	if err := ioutil.WriteFile(tmp, []byte(all), 0600); err != nil {
+	if err := ioutil.WriteFile(tmp, []byte(all.String()), 0600); err != nil {
 		log.Fatalf("Unable to write to temp file: %s", err)
 	}
 
@@ -303,24 +531,18 @@ func findTask(tasks Tasks) []int {
 	return numbers
 }
 
-func backupOriginal(enabled bool, filename string) {
+func backupOriginal(enabled bool) {
+	lockStore()
+
 	if !enabled {
 		return
 	}
 
-	contents, err := ioutil.ReadFile(filename)
-	if err != nil {
-		log.Fatalf("Unable to open %s: %s", filename, err)
-	}
-
-	backup := filename + ".bak"
-	backupErr := ioutil.WriteFile(backup, contents, 0644)
-
-	if backupErr != nil {
-		log.Fatalf("Unable to create backup %s: %s", backup, backupErr)
+	if err := store.Backup(); err != nil {
+		log.Fatalf("%s", err)
 	}
 
-	log.Printf("Backed up original file %s as %s", filename, backup)
+	log.Printf("Backed up original file %s as %s.bak", filename, filename)
 }
 
 func markTasks(input string, tasks Tasks, complete bool) {
@@ -331,47 +553,197 @@ func markTasks(input string, tasks Tasks, complete bool) {
 
 	_, numbers := numbersToTasks(input, tasks, msg)
 
+	// Remember which tasks are blocked so newly unblocked tasks can be reported
+	todo.UpdateBlocked(tasks)
+	wasBlocked := make([]bool, len(tasks))
+	for i, task := range tasks {
+		wasBlocked[i] = task.Blocked
+	}
+
+	// Completion dates are only valid if the task also has a creation date
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	w := workflow()
+
 	for _, task := range numbers {
+		// Completing a task twice keeps the original completion date so stats stay accurate
+		wasCompleted := tasks[task].Completed
 		tasks[task].Completed = complete
+
+		if !complete {
+			tasks[task].CompletionDate = todo.EmptyDate
+		} else if !wasCompleted && !time.Time.IsZero(tasks[task].CreationDate) {
+			tasks[task].CompletionDate = today
+		}
+
+		// Keep an existing status: key in sync
+		if tasks[task].Value("status") == "" {
+			continue
+		} else if complete && len(w.Closed) > 0 {
+			tasks[task].SetValue("status", w.Closed[0])
+		} else if !complete && len(w.States) > 0 {
+			tasks[task].SetValue("status", w.States[0])
+		}
 	}
 
-	writeTasks(filename, tasks)
-}
+	writeTasks(tasks)
 
-func loadTasks(filename string, fatal bool) Tasks {
-	raw, err := ioutil.ReadFile(filename)
-	if err != nil {
-		if fatal {
-			log.Fatalf("Unable to open %s: %s", filename, err)
-		} else {
-			raw = []byte{}
+	todo.UpdateBlocked(tasks)
+	var unblocked Tasks
+	var unblockedNumbers []int
+	for i, task := range tasks {
+		if wasBlocked[i] && !task.Blocked && !task.Completed {
+			unblocked = append(unblocked, task)
+			unblockedNumbers = append(unblockedNumbers, i)
 		}
 	}
 
-	tasks := todo.ParseAll(string(raw))
-	return tasks
+	if len(unblocked) > 0 {
+		log.Printf("The following tasks are no longer blocked:")
+		listNumberedTasks(unblocked, unblockedNumbers)
+	}
 }
 
-func writeTasks(filename string, tasks Tasks) {
-	contents := ""
+// archiveTasks moves all completed tasks to the archive and returns the remaining tasks
+func archiveTasks(tasks Tasks) Tasks {
+	backupOriginal(backup)
 
+	// Cancelled tasks go to their own file so the archive only holds work which was actually done
+	sidecars := archiveCancelled(tasks)
+
+	log.Printf("Archived the following tasks:")
 	for _, task := range tasks {
-		if task.Deleted {
-			continue
+		if task.Completed && !task.Deleted {
+			log.Printf("%s", task)
 		}
+	}
+
+	remaining, err := store.Archive(tasks, sidecars...)
+	if err != nil {
+		log.Fatalf("Unable to archive tasks: %s", err)
+	}
+
+	moveNotes(todo.Filter(todo.Completed()).Select(tasks), "", "archive")
+
+	return remaining
+}
+
+func archiveFilename() string {
+	// Set from the todo.sh environment variables
+	if doneFilename != "" {
+		if doneFilename == filename {
+			log.Fatalf("The archive can't be the task file %s", filename)
+		}
+
+		return doneFilename
+	}
+
+	return sidecarFilename("done")
+}
+
+// sidecarFilename returns the name of a file kept next to the task list, such as FILENAME-time.txt
+func sidecarFilename(suffix string) string {
+	name := todo.SidecarFilename(filename, suffix)
+	if name == filename {
+		log.Fatalf("Unable to keep the %s file next to %s as both would have the same name", suffix, filename)
+	}
+
+	return name
+}
+
+// openStore sets up the file backed store used by every command
+func openStore() {
+	fileStore := todo.NewFileStore(filename)
+	fileStore.ArchiveFilename = archiveFilename()
+	fileStore.BeforeWrite = runPreHook
 
-		contents += fmt.Sprintf("%s\n", task)
+	store = fileStore
+}
+
+// lockStore locks the task list the first time it is about to be modified
+func lockStore() {
+	if unlockStore != nil {
+		return
+	}
+
+	unlock, err := store.Lock()
+	if err == todo.ErrModified {
+		log.Fatalf("%s was modified by another process while this command was running, please try again", filename)
+	} else if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	unlockStore = unlock
+}
+
+// loadTasks loads an arbitrary task file (not necessarily the one used by the store)
+func loadTasks(filename string, fatal bool) Tasks {
+	tasks, err := todo.NewFileStore(filename).Load()
+	if err != nil && fatal {
+		log.Fatalf("%s", err)
 	}
 
-	ioutil.WriteFile(filename, []byte(contents), 0644)
+	return tasks
+}
+
+func loadArchive() Tasks {
+	tasks, err := store.LoadArchive()
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	return tasks
+}
+
+// writeTasks saves the tasks along with any sidecars (such as the trash) in a single write
+func writeTasks(tasks Tasks, sidecars ...todo.Sidecar) {
+	lockStore()
+
+	if err := store.Save(tasks, sidecars...); err != nil {
+		log.Fatalf("Unable to save tasks: %s", err)
+	}
 }
 
 func listTasks(tasks Tasks) string {
-	ret := ""
+	var ret strings.Builder
 	for number, task := range tasks {
-		ret += fmt.Sprintf("%03d %s\n", number + 1, task)
+		fmt.Fprintf(&ret, "%03d %s\n", number + 1, task)
 	}
-	return ret
+	return ret.String()
+}
+
+// listTasksDimmed lists the tasks with the provided indexes with blocked tasks dimmed when writing to a terminal
+func listTasksDimmed(tasks Tasks, numbers []int) string {
+	stat, err := os.Stdout.Stat()
+	dim := err == nil && stat.Mode() & os.ModeCharDevice != 0
+
+	var ret strings.Builder
+	for _, number := range numbers {
+		task := tasks[number]
+
+		marker := ""
+		if task.Note != "" {
+			marker = "*"
+		}
+
+		if task.Blocked && dim {
+			fmt.Fprintf(&ret, "\x1b[2m%03d%s %s\x1b[0m\n", number + 1, marker, task)
+		} else {
+			fmt.Fprintf(&ret, "%03d%s %s\n", number + 1, marker, task)
+		}
+	}
+	return ret.String()
+}
+
+// parseQuery parses a filter query (see todo.ParseFilter), exiting on errors
+func parseQuery(query string) todo.Filter {
+	filter, err := todo.ParseFilter(query)
+	if err != nil {
+		log.Fatalf("Invalid query %q: %s", query, err)
+	}
+
+	return filter
 }
 
 func listNumberedTasks(tasks Tasks, numbers []int) {
@@ -392,7 +764,7 @@ func numbersToTasks(rawNumbers string, tasks Tasks, msg string) (Tasks, []int) {
 		longIndex, err := strconv.ParseInt(i, 10, 32)
 		index := int(longIndex) - 1
 
-		if index < 0 || index > len(tasks) || err != nil {
+		if index < 0 || index >= len(tasks) || err != nil {
 			log.Fatalf("Error: cannot find task with index %d", index)
 		}
 
@@ -401,7 +773,7 @@ func numbersToTasks(rawNumbers string, tasks Tasks, msg string) (Tasks, []int) {
 	}
 
 	if msg != "" {
-		backupOriginal(backup, filename)
+		backupOriginal(backup)
 
 		log.Printf(msg)
 		listNumberedTasks(ret, parsed)
@@ -0,0 +1,126 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Three way merge of todo files, usable as a git merge driver:
+ *	git config merge.todo.name "todo.txt merge"
+ *	git config merge.todo.driver "todo merge %O %A %B"
+ *	echo "todo.txt merge=todo" >> .gitattributes
+ *
+ * The result is written over ours (like git expects) unless -o is given. The exit status is 1 if any conflicts remain.
+ */
+func mergeCommand(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("o", "", "Output filename (defaults to overwriting ours, - for stdout)")
+	interactive := fs.Bool("i", false, "Interactively resolve conflicts instead of writing conflict markers")
+	fs.Parse(args)
+
+	if fs.NArg() != 3 {
+		log.Fatalf("Usage: merge [-i] [-o output] base.txt ours.txt theirs.txt")
+	}
+
+	baseName, ourName, theirName := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+	if *output == "" {
+		*output = ourName
+	}
+
+	// The base may legitimately be missing (i.e. both sides added the file)
+	base := loadTasks(baseName, false)
+	ours := loadTasks(ourName, true)
+	theirs := loadTasks(theirName, true)
+
+	var stdin *bufio.Reader
+	if *interactive {
+		stdin = bufio.NewReader(os.Stdin)
+	}
+
+	var contents strings.Builder
+	conflicts := 0
+
+	for _, result := range todo.Merge(base, ours, theirs) {
+		if !result.Conflict {
+			fmt.Fprintf(&contents, "%s\n", result.Task)
+			continue
+		}
+
+		if *interactive {
+			contents.WriteString(resolveConflict(stdin, result))
+			continue
+		}
+
+		conflicts++
+		fmt.Fprintf(&contents, "<<<<<<< %s\n", ourName)
+		contents.WriteString(optionalTask(result.Ours))
+		contents.WriteString("=======\n")
+		contents.WriteString(optionalTask(result.Theirs))
+		fmt.Fprintf(&contents, ">>>>>>> %s\n", theirName)
+	}
+
+	if *output == "-" {
+		fmt.Print(contents.String())
+	} else if err := todo.WriteFile(*output, []byte(contents.String())); err != nil {
+		log.Fatalf("Unable to write %s: %s", *output, err)
+	}
+
+	if conflicts > 0 {
+		log.Printf("Merge finished with %d conflict(s)", conflicts)
+		return 1
+	}
+
+	return 0
+}
+
+func optionalTask(task *todo.Task) string {
+	if task == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s\n", task)
+}
+
+// resolveConflict asks the user which side of a conflicting task should be kept and returns the chosen line(s)
+func resolveConflict(stdin *bufio.Reader, result todo.MergeResult) string {
+	describe := func(task *todo.Task) string {
+		if task == nil {
+			return "(deleted)"
+		}
+		return task.String()
+	}
+
+	fmt.Printf("Conflict:\n")
+	fmt.Printf("  base:   %s\n", describe(result.Base))
+	fmt.Printf("  ours:   %s\n", describe(result.Ours))
+	fmt.Printf("  theirs: %s\n", describe(result.Theirs))
+
+	for {
+		fmt.Printf("Keep [o]urs, [t]heirs, [b]oth or [n]either? ")
+
+		raw, err := stdin.ReadString('\n')
+		if err != nil {
+			log.Fatalf("Unable to read response: %s", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "o":
+			return optionalTask(result.Ours)
+		case "t":
+			return optionalTask(result.Theirs)
+		case "b":
+			return optionalTask(result.Ours) + optionalTask(result.Theirs)
+		case "n":
+			return ""
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestMergeIndependentChanges(t *testing.T) {
+	base := todo.ParseAll("2020-08-01 first task\n2020-08-01 second task\n2020-08-01 third task id:3\n")
+	ours := todo.ParseAll("x 2020-08-01 first task\n2020-08-01 second task\n2020-08-01 third task id:3\n2020-08-02 our new task\n")
+	theirs := todo.ParseAll("2020-08-01 first task\n(A) 2020-08-01 third task renamed id:3\n2020-08-03 their new task\n")
+
+	expected := []string {
+		"x 2020-08-01 first task",
+		"(A) 2020-08-01 third task renamed id:3",
+		"2020-08-02 our new task",
+		"2020-08-03 their new task",
+	}
+
+	results := todo.Merge(base, ours, theirs)
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d merged tasks but got %d", len(expected), len(results))
+	}
+
+	for i, result := range results {
+		if result.Conflict {
+			t.Errorf("Unexpected conflict for %s", expected[i])
+		} else if result.Task.String() != expected[i] {
+			t.Errorf(getMessage(expected[i], "merge", expected[i], result.Task))
+		}
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	base := todo.ParseAll("2020-08-01 shared task id:1\n2020-08-01 deleted task id:2\n")
+	ours := todo.ParseAll("(A) 2020-08-01 shared task id:1\n")
+	theirs := todo.ParseAll("(B) 2020-08-01 shared task id:1\n2020-08-01 deleted task but edited id:2\n")
+
+	results := todo.Merge(base, ours, theirs)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results but got %d", len(results))
+	}
+
+	for _, result := range results {
+		if !result.Conflict {
+			t.Errorf("Expected a conflict but got %s", result.Task)
+		}
+	}
+
+	if results[1].Ours != nil || results[1].Theirs == nil {
+		t.Errorf("Expected a delete/edit conflict for task 2")
+	}
+}
+
+func TestMergeCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File permissions aren't kept on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string {
+		"base.txt": "first\n",
+		"ours.txt": "x first\n",
+		"theirs.txt": "first\nsecond\n",
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Unable to write %s: %s", name, err)
+		}
+	}
+
+	ours := filepath.Join(dir, "ours.txt")
+	if status := mergeCommand([]string{ filepath.Join(dir, "base.txt"), ours, filepath.Join(dir, "theirs.txt") }); status != 0 {
+		t.Errorf(getMessage("merge", "exit status", 0, status))
+	}
+
+	// The result replaces ours through a temporary file which keeps its permissions
+	if contents, _ := ioutil.ReadFile(ours); string(contents) != "x first\nsecond\n" {
+		t.Errorf(getMessage("merge", "result", "x first\nsecond\n", string(contents)))
+	}
+
+	if info, err := os.Stat(ours); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected %s to keep its permissions (%v)", ours, err)
+	}
+
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != len(files) {
+		t.Errorf("Expected no temporary files to be left in %s, found %d files", dir, len(entries))
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Notes:
+ * Every task can be linked to a Markdown file in the notes directory through its note: key.
+ * Notes of archived tasks are moved to the archive subdirectory and notes of removed tasks to the trash subdirectory.
+ */
+
+func notesDir() string {
+	if config.NotesDir != "" {
+		return config.NotesDir
+	}
+
+	return filepath.Join(filepath.Dir(filename), "notes")
+}
+
+// notePath returns the location of a note, looking in the archive and trash for notes of archived or removed tasks
+func notePath(note string) string {
+	for _, dir := range []string { "", "archive", "trash" } {
+		path := filepath.Join(notesDir(), dir, note)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return filepath.Join(notesDir(), note)
+}
+
+// slug lowercases a word and drops everything but letters and digits
+func slug(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
+
+// noteName creates a filename from the first words of the description and the task's id: (or part of its hash if it has none)
+func noteName(task todo.Task) string {
+	var words []string
+
+	for _, word := range strings.Fields(task.Description) {
+		if strings.ContainsAny(word[:1], "+@") || strings.Contains(word, ":") {
+			continue
+		}
+
+		if word = slug(word); word != "" {
+			words = append(words, word)
+		}
+
+		if len(words) == 5 {
+			break
+		}
+	}
+
+	suffix := slug(task.ID)
+	if suffix == "" {
+		hash := sha256.Sum256([]byte(task.Description))
+		suffix = hex.EncodeToString(hash[:])[:4]
+	}
+
+	words = append(words, suffix)
+	return strings.Join(words, "-") + ".md"
+}
+
+// safeNote returns the note: of a task if it is a plain filename. Since task files are often shared,
+// anything that could point outside of the notes directory is ignored with a warning.
+func safeNote(task todo.Task) string {
+	note := task.Note
+	if note == "" {
+		return ""
+	}
+
+	if filepath.Base(note) != note || note == "." || note == ".." {
+		log.Printf("Warning: ignoring note %s of task %s as it isn't a plain filename", note, task.Description)
+		return ""
+	}
+
+	return note
+}
+
+// editNote opens the note of a task in the default editor, creating and linking it first if needed
+func editNote(input string, tasks Tasks) {
+	_, numbers := numbersToTasks(input, tasks, "")
+	if len(numbers) != 1 {
+		log.Fatalf("You must provide exactly one task number")
+	}
+
+	task := &tasks[numbers[0]]
+
+	// An unsafe note is replaced by a new one
+	if safeNote(*task) == "" {
+		backupOriginal(backup)
+
+		task.SetValue("note", noteName(*task))
+		writeTasks(tasks)
+
+		log.Printf("Linked note %s to %s", task.Note, task)
+	}
+
+	path := notePath(task.Note)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatalf("Unable to create notes directory: %s", err)
+		}
+
+		header := fmt.Sprintf("# %s\n\n", task.Description)
+		if err := ioutil.WriteFile(path, []byte(header), 0644); err != nil {
+			log.Fatalf("Unable to create note %s: %s", path, err)
+		}
+	}
+
+	cmd := exec.Command("editor", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Fatalf("Unable to execute editor: %s", err)
+	}
+}
+
+// showTasks prints each task followed by its note
+func showTasks(input string, tasks Tasks) {
+	selected, numbers := numbersToTasks(input, tasks, "")
+	if len(selected) == 0 {
+		log.Fatalf("You must provide at least one task number")
+	}
+
+	for i, task := range selected {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("%03d %s\n", numbers[i] + 1, task)
+
+		note := safeNote(task)
+		if note == "" {
+			continue
+		}
+
+		raw, err := ioutil.ReadFile(notePath(note))
+		if err != nil {
+			log.Printf("Unable to open note %s: %s", note, err)
+			continue
+		}
+
+		fmt.Printf("\n%s", raw)
+		if !strings.HasSuffix(string(raw), "\n") {
+			fmt.Println()
+		}
+	}
+}
+
+// moveNotes moves the notes of the provided tasks between subdirectories of the notes directory ("" is the notes directory itself)
+func moveNotes(tasks Tasks, from, to string) {
+	for _, task := range tasks {
+		note := safeNote(task)
+		if note == "" {
+			continue
+		}
+
+		source := filepath.Join(notesDir(), from, note)
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+
+		destination := filepath.Join(notesDir(), to, note)
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			log.Fatalf("Unable to create %s: %s", filepath.Dir(destination), err)
+		}
+
+		if err := os.Rename(source, destination); err != nil {
+			log.Printf("Unable to move note %s: %s", source, err)
+			continue
+		}
+
+		log.Printf("Moved note %s to %s", note, destination)
+	}
+}
+
+// deleteNotes removes the notes of tasks from the subdirectory dir of the notes directory ("" for active tasks)
+func deleteNotes(tasks Tasks, dir string) {
+	for _, task := range tasks {
+		note := safeNote(task)
+		if note == "" {
+			continue
+		}
+
+		path := filepath.Join(notesDir(), dir, note)
+		if err := os.Remove(path); err == nil {
+			log.Printf("Deleted note %s", path)
+		} else if !os.IsNotExist(err) {
+			log.Printf("Unable to delete note %s: %s", path, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestNotes(t *testing.T) {
+	task := todo.ParseTask("(A) Write the Q3 report! +work @office due:2020-08-01")
+
+	name := noteName(task)
+	if !strings.HasPrefix(name, "write-the-q3-report-") || !strings.HasSuffix(name, ".md") {
+		t.Errorf("Unexpected note name %s", name)
+	}
+
+	task.SetValue("note", name)
+	if parsed := todo.ParseTask(task.String()); parsed.Note != name {
+		t.Errorf("Expected note %s, got %s", name, parsed.Note)
+	}
+
+	if task := todo.ParseTask("no note here"); task.Note != "" {
+		t.Errorf("Expected no note, got %s", task.Note)
+	}
+
+	// Tasks with an id: use it instead of a hash so similar tasks don't share a note
+	first, second := noteName(todo.ParseTask("standup id:42")), noteName(todo.ParseTask("standup id:43"))
+	if first != "standup-42.md" || second != "standup-43.md" {
+		t.Errorf(getMessage("standup id:42", "note name", "standup-42.md", first))
+	}
+}
+
+func TestUnsafeNotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saveGlobals(t)
+	filename = filepath.Join(dir, "todo.txt")
+
+	secret := filepath.Join(dir, "secret.txt")
+	if err := ioutil.WriteFile(secret, []byte("secret"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", secret, err)
+	}
+
+	for _, note := range []string{ "../secret.txt", "..", ".", "sub/note.md", "/etc/passwd" } {
+		if actual := safeNote(todo.ParseTask("task note:" + note)); actual != "" {
+			t.Errorf(getMessage("task note:" + note, "safe note", "", actual))
+		}
+	}
+
+	if actual := safeNote(todo.ParseTask("task note:plain.md")); actual != "plain.md" {
+		t.Errorf(getMessage("task note:plain.md", "safe note", "plain.md", actual))
+	}
+
+	// Removing a task must never move files outside of the notes directory
+	moveNotes(Tasks{ todo.ParseTask("task note:../secret.txt") }, "", "trash")
+
+	if _, err := os.Stat(secret); err != nil {
+		t.Errorf("%s was moved: %s", secret, err)
+	}
+}
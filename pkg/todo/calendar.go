@@ -0,0 +1,299 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single appointment from a calendar
+type Event struct {
+	Summary    string
+	Start      time.Time
+	End        time.Time
+	Recurrence *Recurrence		// nil unless the event repeats
+
+	uid          string
+	recurrenceID time.Time		// Start of the occurrence of a recurring event which this event replaces
+}
+
+// Recurrence is a daily or weekly RRULE along with the occurrences excluded by EXDATE
+type Recurrence struct {
+	Frequency string				// DAILY or WEEKLY
+	Interval  int
+	Count     int					// Number of occurrences, 0 if unlimited
+	Until     time.Time				// Last possible start, zero if unlimited
+	Weekdays  []time.Weekday		// BYDAY, weekly rules default to the weekday of the first occurrence
+	Except    map[string]bool		// Excluded starts (RFC 3339 in UTC) or dates (YYYY-MM-DD)
+}
+
+// ReadICS returns the timed events of an iCalendar (.ics) file.
+// All day events are skipped. Daily and weekly recurring events are expanded by Occurrences, other rules only return their first occurrence.
+func ReadICS(r io.Reader) ([]Event, error) {
+	var lines []string
+
+	// Long lines are folded by starting the continuation with a space or tab
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines) - 1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+
+	for _, line := range lines {
+		colon := strings.Index(line, ":")
+		if colon == -1 {
+			continue
+		}
+
+		// Parameters such as TZID are separated from the name by semicolons
+		params := strings.Split(line[:colon], ";")
+		name, value := strings.ToUpper(params[0]), line[colon + 1:]
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+
+		case name == "END" && value == "VEVENT" && current != nil:
+			if !current.Start.IsZero() && current.End.After(current.Start) {
+				events = append(events, *current)
+			}
+			current = nil
+
+		case current == nil:
+			continue
+
+		case name == "SUMMARY":
+			current.Summary = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\\`, `\`).Replace(value)
+
+		case name == "DTSTART":
+			current.Start = parseICSTime(value, params[1:])
+
+		case name == "DTEND":
+			current.End = parseICSTime(value, params[1:])
+
+		case name == "UID":
+			current.uid = value
+
+		case name == "RECURRENCE-ID":
+			current.recurrenceID = parseICSTime(value, params[1:])
+
+		case name == "RRULE":
+			current.Recurrence = parseRRule(value, current.Recurrence)
+
+		case name == "EXDATE":
+			if current.Recurrence == nil {
+				current.Recurrence = &Recurrence{}
+			}
+			for _, date := range strings.Split(value, ",") {
+				current.Recurrence.exclude(date, params[1:])
+			}
+		}
+	}
+
+	// Occurrences which were moved are replaced by their own event
+	for _, event := range events {
+		if event.recurrenceID.IsZero() {
+			continue
+		}
+
+		for _, master := range events {
+			if master.uid == event.uid && master.Recurrence != nil {
+				master.Recurrence.Except[icsKey(event.recurrenceID)] = true
+			}
+		}
+	}
+
+	// Events with an EXDATE but no supported RRULE only happen once
+	for i := range events {
+		if events[i].Recurrence != nil && events[i].Recurrence.Frequency == "" {
+			events[i].Recurrence = nil
+		}
+	}
+
+	return events, nil
+}
+
+// parseRRule parses the parts of an RRULE which are needed for daily and weekly events, keeping any exceptions which were already read
+func parseRRule(value string, existing *Recurrence) *Recurrence {
+	rule := &Recurrence{ Interval: 1, Except: make(map[string]bool) }
+	if existing != nil {
+		rule.Except = existing.Except
+	}
+
+	weekdays := map[string]time.Weekday {
+		"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+		"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch strings.ToUpper(kv[0]) {
+		case "FREQ":
+			rule.Frequency = strings.ToUpper(kv[1])
+		case "INTERVAL":
+			if interval, err := strconv.Atoi(kv[1]); err == nil && interval > 0 {
+				rule.Interval = interval
+			}
+		case "COUNT":
+			rule.Count, _ = strconv.Atoi(kv[1])
+		case "UNTIL":
+			rule.Until = parseICSTime(kv[1], nil)
+			if rule.Until.IsZero() {
+				// Dates without a time include the whole day
+				if date, err := time.ParseInLocation("20060102", kv[1], time.Local); err == nil {
+					rule.Until = date.AddDate(0, 0, 1).Add(-time.Second)
+				}
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(kv[1], ",") {
+				// Ordinals like 1MO only apply to monthly and yearly rules
+				if weekday, ok := weekdays[strings.ToUpper(day)]; ok {
+					rule.Weekdays = append(rule.Weekdays, weekday)
+				}
+			}
+		}
+	}
+
+	if rule.Frequency != "DAILY" && rule.Frequency != "WEEKLY" {
+		rule.Frequency = ""
+	}
+
+	return rule
+}
+
+func icsKey(start time.Time) string {
+	return start.UTC().Format(time.RFC3339)
+}
+
+// exclude adds an EXDATE value, which is either a time or a whole date
+func (r *Recurrence) exclude(value string, params []string) {
+	if r.Except == nil {
+		r.Except = make(map[string]bool)
+	}
+
+	if len(value) == 8 {
+		if date, err := time.Parse("20060102", value); err == nil {
+			r.Except[formatYMD(date)] = true
+		}
+		return
+	}
+
+	if start := parseICSTime(value, params); !start.IsZero() {
+		r.Except[icsKey(start)] = true
+	}
+}
+
+func (r *Recurrence) excluded(start time.Time) bool {
+	return r.Except[icsKey(start)] || r.Except[formatYMD(start)]
+}
+
+// matches returns true if the rule has an occurrence on the day which is the provided number of days after the first occurrence
+func (r *Recurrence) matches(first time.Time, days int) bool {
+	day := first.AddDate(0, 0, days)
+
+	weekdays := r.Weekdays
+	if len(weekdays) == 0 && r.Frequency == "WEEKLY" {
+		weekdays = []time.Weekday{ first.Weekday() }
+	}
+
+	if len(weekdays) > 0 {
+		found := false
+		for _, weekday := range weekdays {
+			found = found || weekday == day.Weekday()
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.Frequency == "DAILY" {
+		return days % r.Interval == 0
+	}
+
+	// Weeks start on Monday
+	offset := (int(first.Weekday()) + 6) % 7
+	return ((days + offset) / 7) % r.Interval == 0
+}
+
+// Occurrences returns every occurrence of the event which overlaps the time between start and end
+func (e Event) Occurrences(start, end time.Time) []Event {
+	overlaps := func(from, to time.Time) bool {
+		return to.After(start) && from.Before(end)
+	}
+
+	if e.Recurrence == nil {
+		if overlaps(e.Start, e.End) {
+			return []Event{ e }
+		}
+		return nil
+	}
+
+	var occurrences []Event
+	r := e.Recurrence
+	length := e.End.Sub(e.Start)
+	count := 0
+
+	for days := 0; ; days++ {
+		// AddDate keeps the wall clock time across daylight saving changes
+		occurrence := e.Start.AddDate(0, 0, days)
+		if !occurrence.Before(end) || (!r.Until.IsZero() && occurrence.After(r.Until)) {
+			break
+		}
+
+		if !r.matches(e.Start, days) {
+			continue
+		}
+
+		// Excluded occurrences still count towards COUNT
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+
+		if !r.excluded(occurrence) && overlaps(occurrence, occurrence.Add(length)) {
+			occurrences = append(occurrences, Event{ Summary: e.Summary, Start: occurrence, End: occurrence.Add(length), uid: e.uid })
+		}
+	}
+
+	return occurrences
+}
+
+// parseICSTime parses UTC (20200815T090000Z), floating (local) and TZID times. Dates without a time return the zero time.
+func parseICSTime(value string, params []string) time.Time {
+	location := time.Local
+	for _, param := range params {
+		if strings.HasPrefix(strings.ToUpper(param), "TZID=") {
+			if loaded, err := time.LoadLocation(param[5:]); err == nil {
+				location = loaded
+			}
+		}
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		parsed, _ := time.Parse("20060102T150405Z", value)
+		return parsed
+	}
+
+	parsed, _ := time.ParseInLocation("20060102T150405", value, location)
+	return parsed
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SyntaxError is returned by a strict Decoder for lines which don't follow the todo.txt format
+type SyntaxError struct {
+	Line int		// 1 based line number
+	Text string		// Contents of the line
+	Msg  string		// Description of the problem
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s: %q", e.Line, e.Msg, e.Text)
+}
+
+// lineReader splits a stream into lines, keeping track of the line number and line ending
+type lineReader struct {
+	reader *bufio.Reader
+	line   int
+	done   bool
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{ reader: bufio.NewReaderSize(r, 64 * 1024) }
+}
+
+// next returns the next line without its line ending or io.EOF once the stream is exhausted
+func (l *lineReader) next() (string, string, error) {
+	if l.done {
+		return "", "", io.EOF
+	}
+
+	text, err := l.reader.ReadString('\n')
+	if err == io.EOF {
+		l.done = true
+		if text == "" {
+			return "", "", io.EOF
+		}
+	} else if err != nil {
+		return "", "", err
+	}
+
+	l.line++
+
+	ending := ""
+	if strings.HasSuffix(text, "\n") {
+		text = text[:len(text) - 1]
+		ending = "\n"
+
+		// Handles newlines on Windows
+		if strings.HasSuffix(text, "\r") {
+			text = text[:len(text) - 1]
+			ending = "\r\n"
+		}
+	}
+
+	return text, ending, nil
+}
+
+// A Decoder reads tasks one at a time from a stream, skipping blank lines and comments
+//
+//	dec := todo.NewDecoder(os.Stdin)
+//	for {
+//		task, err := dec.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+type Decoder struct {
+	lines   *lineReader
+	line    int
+	strict  bool
+	layouts []string
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{ lines: newLineReader(r) }
+}
+
+// Strict makes Next return a *SyntaxError for malformed lines (invalid dates or priorities) instead of parsing them leniently
+func (d *Decoder) Strict() {
+	d.strict = true
+}
+
+// DateLayouts adds time layouts (as used by time.Parse) which are accepted for the completion, creation and due dates in addition to YYYY-MM-DD.
+// Dates in these layouts are converted to YYYY-MM-DD.
+func (d *Decoder) DateLayouts(layouts ...string) {
+	d.layouts = append(d.layouts, layouts...)
+}
+
+// Line returns the line number of the task most recently returned by Next
+func (d *Decoder) Line() int {
+	return d.line
+}
+
+// Next returns the next task in the stream or io.EOF if there are no more tasks
+func (d *Decoder) Next() (Task, error) {
+	for {
+		text, _, err := d.lines.next()
+		if err != nil {
+			return Task{}, err
+		}
+
+		if !isTaskLine(text) {
+			continue
+		}
+
+		d.line = d.lines.line
+
+		if len(d.layouts) > 0 {
+			text = d.normalizeDates(text)
+		}
+
+		if d.strict {
+			if msg := validate(text); msg != "" {
+				return Task{}, &SyntaxError{ Line: d.line, Text: text, Msg: msg }
+			}
+		}
+
+		return ParseTask(text), nil
+	}
+}
+
+// normalizeDates converts dates in the leading date positions and due: values from the custom layouts to YYYY-MM-DD
+func (d *Decoder) normalizeDates(text string) string {
+	fields := strings.Split(text, " ")
+
+	// Skip completion and priority
+	i := 0
+	if i < len(fields) && fields[i] == "x" {
+		i++
+	}
+	if i < len(fields) && isPriority(fields[i]) {
+		i++
+	}
+
+	// Completion and creation dates
+	for end := i + 2; i < end && i < len(fields); i++ {
+		converted, ok := d.convertDate(fields[i])
+		if !ok {
+			break
+		}
+		fields[i] = converted
+	}
+
+	for ; i < len(fields); i++ {
+		if strings.HasPrefix(fields[i], "due:") {
+			if converted, ok := d.convertDate(fields[i][4:]); ok {
+				fields[i] = "due:" + converted
+			}
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+func (d *Decoder) convertDate(raw string) (string, bool) {
+	if len(raw) == len(dateLayout) && isDate(raw) {
+		return raw, true
+	}
+
+	for _, layout := range d.layouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed.Format(dateLayout), true
+		}
+	}
+
+	return raw, false
+}
+
+// validate returns a description of the first problem with a line or "" if it is well formed
+func validate(text string) string {
+	fields := strings.Fields(text)
+	completed := false
+
+	i := 0
+	if fields[i] == "x" {
+		completed = true
+		i++
+	}
+
+	if i < len(fields) && strings.HasPrefix(fields[i], "(") && strings.HasSuffix(fields[i], ")") && len(fields[i]) <= 4 {
+		if len(fields[i]) != 3 || !isPriority(fields[i]) {
+			return "invalid priority " + fields[i]
+		}
+		i++
+	}
+
+	dates := 0
+	for ; i < len(fields) && dates < 2 && isDate(fields[i]); i++ {
+		if _, err := time.Parse(dateLayout, fields[i]); err != nil || len(fields[i]) != len(dateLayout) {
+			return "invalid date " + fields[i]
+		}
+		dates++
+	}
+
+	if dates == 2 && !completed {
+		return "completion date on an incomplete task"
+	}
+
+	if i == len(fields) {
+		return "missing description"
+	}
+
+	for ; i < len(fields); i++ {
+		if strings.HasPrefix(fields[i], "due:") {
+			if _, err := time.Parse(dateLayout, fields[i][4:]); err != nil {
+				return "invalid due date " + fields[i]
+			}
+		}
+	}
+
+	return ""
+}
+
+// An Encoder writes tasks to a stream, one per line. Every task is written with a single call to Write.
+type Encoder struct {
+	w       io.Writer
+	newline string
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{ w: w, newline: "\n" }
+}
+
+// SetNewline changes the line ending written after every task (i.e. "\r\n")
+func (e *Encoder) SetNewline(newline string) {
+	e.newline = newline
+}
+
+// Encode writes a single task. Deleted tasks are skipped.
+func (e *Encoder) Encode(task Task) error {
+	if task.Deleted {
+		return nil
+	}
+
+	_, err := io.WriteString(e.w, task.String() + e.newline)
+	return err
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+// UpdateBlocked marks every task which depends on an open task as blocked.
+// Dependencies on IDs which aren't in the list (i.e. archived tasks) are treated as completed.
+func UpdateBlocked(tasks []Task) {
+	open := make(map[string]bool)
+	for _, task := range tasks {
+		if task.ID != "" && !task.Completed && !task.Deleted {
+			open[task.ID] = true
+		}
+	}
+
+	for i := range tasks {
+		tasks[i].Blocked = false
+
+		for _, dep := range tasks[i].Dependencies {
+			if open[dep] {
+				tasks[i].Blocked = true
+				break
+			}
+		}
+	}
+}
+
+// FindByID returns the index of the task with the provided id: value or -1 if it doesn't exist
+func FindByID(tasks []Task, id string) int {
+	for i, task := range tasks {
+		if task.ID == id && !task.Deleted {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// FindCycle returns the chain of IDs forming a dependency cycle reachable from the provided task or nil if there isn't one.
+// The first and last element of a returned cycle are identical.
+func FindCycle(tasks []Task, start int) []string {
+	return findCycle(tasks, tasks[start], nil)
+}
+
+func findCycle(tasks []Task, task Task, path []string) []string {
+	if task.ID != "" {
+		for i, seen := range path {
+			if seen == task.ID {
+				return append(append([]string{}, path[i:]...), task.ID)
+			}
+		}
+
+		path = append(path, task.ID)
+	}
+
+	for _, dep := range task.Dependencies {
+		index := FindByID(tasks, dep)
+		if index == -1 {
+			continue
+		}
+
+		if cycle := findCycle(tasks, tasks[index], path); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
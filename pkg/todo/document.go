@@ -0,0 +1,219 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Document is a lossless model of a todo.txt file.
+// Blank lines, comments (lines starting with "# " or a lone #), the line endings and whether the file ends with a newline
+// are all kept, so rendering an unmodified document returns exactly the bytes it was parsed from.
+type Document struct {
+	Tasks []Task				// Tasks in file order, the same as returned by ParseAll
+
+	blocks          []docBlock	// Every task line along with the blank lines and comments before it
+	trailing        []docLine	// Blank lines and comments after the last task
+	newline         string		// Line ending used for new lines (the first one found in the file)
+	trailingNewline bool		// If the last line ends with a line ending
+	raw             map[string]string	// Original text of every task, keyed by its serialization
+}
+
+type docLine struct {
+	text   string		// Line contents without the line ending
+	ending string		// "\n", "\r\n" or "" for a final line without a line ending
+}
+
+// docBlock is a task line and the blank lines and comments leading up to it, which move along with the task
+type docBlock struct {
+	leading []docLine
+	line    docLine
+	hash    string		// Hash of the task parsed from line
+}
+
+// isTaskLine returns false for blank lines and comments. Only "# " and a lone # start a comment so tasks can begin
+// with a hashtag, i.e. "#hashtag fix build".
+func isTaskLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && trimmed != "#" && !strings.HasPrefix(trimmed, "# ") && !strings.HasPrefix(trimmed, "#\t")
+}
+
+func ParseDocument(contents string) *Document {
+	// Reading from a string can't fail
+	doc, _ := ReadDocument(strings.NewReader(contents))
+	return doc
+}
+
+// ReadDocument parses a document line by line from r
+func ReadDocument(r io.Reader) (*Document, error) {
+	doc := &Document {
+		newline: "\n",
+		trailingNewline: true,
+		raw: make(map[string]string),
+	}
+
+	lines := newLineReader(r)
+	foundNewline := false
+
+	// Blank lines and comments are collected until the task they lead up to is found
+	var leading []docLine
+	var last docLine
+
+	for {
+		text, ending, err := lines.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		line := docLine { text: text, ending: ending }
+
+		if !foundNewline && line.ending != "" {
+			doc.newline = line.ending
+			foundNewline = true
+		}
+
+		if !isTaskLine(line.text) {
+			leading = append(leading, line)
+			last = line
+			continue
+		}
+
+		task, serialized := parseTask(line.text)
+
+		doc.Tasks = append(doc.Tasks, task)
+		doc.raw[serialized] = line.text
+		doc.blocks = append(doc.blocks, docBlock{ leading: leading, line: line, hash: task.Hash })
+
+		leading = nil
+		last = line
+	}
+
+	doc.trailing = leading
+	doc.trailingNewline = last.ending != "" || (len(doc.blocks) == 0 && len(doc.trailing) == 0)
+
+	return doc, nil
+}
+
+// text returns the original text of a task if it hasn't been modified since parsing, otherwise its serialization
+func (d *Document) text(task Task) string {
+	serialized := task.String()
+	if raw, ok := d.raw[serialized]; ok {
+		return raw
+	}
+
+	return serialized
+}
+
+// String renders the document with its current tasks
+func (d *Document) String() string {
+	return d.Render(d.Tasks)
+}
+
+// Render returns the contents of the document with the task lines replaced by the provided tasks.
+// Tasks are matched to their original lines by the hash set when they were parsed, so the blank lines and comments before
+// a task move along with it when the tasks are reordered. Tasks without a match (such as edited tasks) take over the
+// remaining task lines in order. Lines after the last task stay at the end of the file.
+// Deleted tasks remove their line but keep the lines before it, other tasks are written where they appear in tasks.
+func (d *Document) Render(tasks []Task) string {
+	var builder strings.Builder
+
+	// Writing to a strings.Builder can't fail
+	d.RenderTo(&builder, tasks)
+
+	return builder.String()
+}
+
+// RenderTo is Render but streams the contents to w
+func (d *Document) RenderTo(w io.Writer, tasks []Task) error {
+	writer := bufio.NewWriterSize(w, 64 * 1024)
+
+	// The ending of every line is only written once the next line is known, since only the last line decides if the file ends with a newline
+	wrote := false
+	pending := ""
+	emit := func(text, ending string) {
+		if wrote {
+			if pending == "" {
+				pending = d.newline
+			}
+			writer.WriteString(pending)
+		}
+
+		writer.WriteString(text)
+		pending = ending
+		wrote = true
+	}
+
+	emitLines := func(lines []docLine) {
+		for _, line := range lines {
+			emit(line.text, line.ending)
+		}
+	}
+
+	// Identical tasks have the same hash and are matched to their lines in file order
+	blocks := make(map[string][]int)
+	for i, block := range d.blocks {
+		blocks[block.hash] = append(blocks[block.hash], i)
+	}
+
+	assigned := make([]int, len(tasks))
+	used := make([]bool, len(d.blocks))
+	for t, task := range tasks {
+		assigned[t] = -1
+
+		if matches := blocks[task.Hash]; task.Hash != "" && len(matches) > 0 {
+			assigned[t] = matches[0]
+			blocks[task.Hash] = matches[1:]
+			used[matches[0]] = true
+		}
+	}
+
+	// Tasks which were replaced (i.e. by edit) take over the remaining lines in order
+	next := 0
+	for t := range tasks {
+		for next < len(used) && used[next] {
+			next++
+		}
+
+		if assigned[t] == -1 && next < len(used) {
+			assigned[t] = next
+			used[next] = true
+		}
+	}
+
+	for t, task := range tasks {
+		ending := d.newline
+
+		if i := assigned[t]; i != -1 {
+			emitLines(d.blocks[i].leading)
+			ending = d.blocks[i].line.ending
+		}
+
+		if !task.Deleted {
+			emit(d.text(task), ending)
+		}
+	}
+
+	// Task lines without a corresponding task were removed, but the lines before them are kept
+	for i, block := range d.blocks {
+		if !used[i] {
+			emitLines(block.leading)
+		}
+	}
+
+	emitLines(d.trailing)
+
+	// Keep the original ending of an unchanged last line
+	if wrote && d.trailingNewline {
+		if pending == "" {
+			pending = d.newline
+		}
+		writer.WriteString(pending)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileWrite is a task file which is about to be written along with its new tasks
+type FileWrite struct {
+	Filename string
+	Tasks    []Task
+}
+
+// SidecarFilename returns the name of a file kept next to filename, i.e. todo.txt and the suffix done give todo-done.txt
+func SidecarFilename(filename, suffix string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + "-" + suffix + ".txt"
+}
+
+// writeTemp creates a temporary file next to target with the same permissions and fills it using write
+func writeTemp(filename, target string, write func(io.Writer) error) (string, error) {
+	var mode os.FileMode = 0644
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(target), filepath.Base(target) + ".tmp")
+	if err != nil {
+		return "", fmt.Errorf("unable to write %s: %w", filename, err)
+	}
+	temp := file.Name()
+
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp, mode)
+	}
+
+	if err != nil {
+		os.Remove(temp)
+		return "", fmt.Errorf("unable to write %s: %w", filename, err)
+	}
+
+	return temp, nil
+}
+
+// WriteFile replaces filename (or the file it links to) with contents by renaming a temporary file over it, so readers
+// never see a partially written file
+func WriteFile(filename string, contents []byte) error {
+	target := filename
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		target = resolved
+	}
+
+	temp, err := writeTemp(filename, target, func(w io.Writer) error {
+		_, err := w.Write(contents)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp, target); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("unable to write %s: %w", filename, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,385 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter selects tasks. Filters can be combined with And, Or and Not or parsed from a query with ParseFilter.
+type Filter func(task Task) bool
+
+// Indexes returns the index of every task matched by the filter
+func (f Filter) Indexes(tasks []Task) []int {
+	var matched []int
+
+	for i, task := range tasks {
+		if f(task) {
+			matched = append(matched, i)
+		}
+	}
+
+	return matched
+}
+
+// Select returns every task matched by the filter
+func (f Filter) Select(tasks []Task) []Task {
+	var matched []Task
+
+	for _, task := range tasks {
+		if f(task) {
+			matched = append(matched, task)
+		}
+	}
+
+	return matched
+}
+
+// All matches every task
+func All() Filter {
+	return func(task Task) bool { return true }
+}
+
+// And matches tasks matched by every filter
+func And(filters ...Filter) Filter {
+	return func(task Task) bool {
+		for _, f := range filters {
+			if !f(task) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches tasks matched by any filter
+func Or(filters ...Filter) Filter {
+	return func(task Task) bool {
+		for _, f := range filters {
+			if f(task) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches tasks which aren't matched by f
+func Not(f Filter) Filter {
+	return func(task Task) bool { return !f(task) }
+}
+
+// Completed matches completed tasks
+func Completed() Filter {
+	return func(task Task) bool { return task.Completed }
+}
+
+// Blocked matches tasks which depend on an open task (see UpdateBlocked)
+func Blocked() Filter {
+	return func(task Task) bool { return task.Blocked }
+}
+
+// DueBetween matches tasks due strictly after lower and strictly before upper. Tasks without a due date never match.
+func DueBetween(lower, upper time.Time) Filter {
+	return func(task Task) bool {
+		return !task.DueDate.IsZero() && lower.Before(task.DueDate) && task.DueDate.Before(upper)
+	}
+}
+
+// HasProject matches tasks tagged with the project (with or without the leading +)
+func HasProject(project string) Filter {
+	project = "+" + strings.TrimPrefix(project, "+")
+
+	return func(task Task) bool {
+		for _, p := range task.Projects() {
+			if p == project {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasContext matches tasks tagged with the context (with or without the leading @)
+func HasContext(context string) Filter {
+	context = "@" + strings.TrimPrefix(context, "@")
+
+	return func(task Task) bool {
+		for _, c := range task.Contexts() {
+			if c == context {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PriorityAtLeast matches tasks with the provided priority or a more important one (i.e. B matches A and B)
+func PriorityAtLeast(priority string) Filter {
+	priority = strings.ToUpper(priority)
+
+	return func(task Task) bool {
+		return task.Priority != "" && task.Priority <= priority
+	}
+}
+
+// TextMatches matches tasks whose text (including priority and dates) matches the regular expression
+func TextMatches(re *regexp.Regexp) Filter {
+	return func(task Task) bool { return re.MatchString(task.String()) }
+}
+
+// KeyEquals matches tasks with a key:value pair
+func KeyEquals(key, value string) Filter {
+	return func(task Task) bool { return task.Value(key) == value }
+}
+
+/* ParseFilter builds a filter from a query. Terms are combined with AND unless separated by OR, and can be grouped with parentheses.
+ *	+project @context		tagged with the project or context
+ *	pri:A pri>=B			exact priority or at least the priority
+ *	is:done is:open is:blocked
+ *	due<DATE due<=DATE due>DATE due>=DATE	DATE is YYYY-MM-DD, today, tomorrow or an offset from today like 7d or -3d
+ *	key:value				key:value pair
+ *	/regex/					regular expression on the whole task (use \s instead of spaces)
+ *	-term NOT term			negation
+ *	word					case insensitive substring
+ */
+func ParseFilter(query string) (Filter, error) {
+	p := &filterParser{ tokens: tokenize(query) }
+	if len(p.tokens) == 0 {
+		return All(), nil
+	}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in query", p.tokens[p.pos])
+	}
+
+	return f, nil
+}
+
+// tokenize splits a query on whitespace with parentheses as separate tokens (except inside a /regex/)
+func tokenize(query string) []string {
+	var tokens []string
+
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "/") || strings.HasPrefix(field, "-/") {
+			tokens = append(tokens, field)
+			continue
+		}
+
+		for strings.HasPrefix(field, "(") {
+			tokens = append(tokens, "(")
+			field = field[1:]
+		}
+
+		closing := 0
+		for strings.HasSuffix(field, ")") {
+			closing++
+			field = field[:len(field) - 1]
+		}
+
+		if field != "" {
+			tokens = append(tokens, field)
+		}
+
+		for ; closing > 0; closing-- {
+			tokens = append(tokens, ")")
+		}
+	}
+
+	return tokens
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	var filters []Filter
+
+	for {
+		f, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+
+		if p.peek() != "OR" {
+			break
+		}
+		p.pos++
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return Or(filters...), nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	var filters []Filter
+
+	for next := p.peek(); next != "" && next != "OR" && next != ")"; next = p.peek() {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("expected a search term")
+	} else if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return And(filters...), nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	token := p.peek()
+	p.pos++
+
+	switch {
+	case token == "NOT":
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(f), nil
+
+	case token == "(":
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+
+		return f, nil
+
+	case len(token) > 1 && strings.HasPrefix(token, "-"):
+		f, err := parseTerm(token[1:], time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return Not(f), nil
+	}
+
+	return parseTerm(token, time.Now())
+}
+
+var dueComparison = regexp.MustCompile("^due(<=|>=|<|>)(.+)$")
+
+// parseTerm converts a single search term into a filter
+func parseTerm(term string, now time.Time) (Filter, error) {
+	switch {
+	case len(term) > 2 && strings.HasPrefix(term, "/") && strings.HasSuffix(term, "/"):
+		re, err := regexp.Compile(term[1:len(term) - 1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %w", term, err)
+		}
+		return TextMatches(re), nil
+
+	case len(term) > 1 && strings.HasPrefix(term, "+"):
+		return HasProject(term), nil
+
+	case len(term) > 1 && strings.HasPrefix(term, "@"):
+		return HasContext(term), nil
+
+	case term == "is:done" || term == "is:completed":
+		return Completed(), nil
+
+	case term == "is:open":
+		return Not(Completed()), nil
+
+	case term == "is:blocked":
+		return Blocked(), nil
+
+	case strings.HasPrefix(term, "pri>="):
+		priority := strings.ToUpper(term[5:])
+		if !ValidPriority(priority) {
+			return nil, fmt.Errorf("invalid priority in %s", term)
+		}
+		return PriorityAtLeast(priority), nil
+
+	case strings.HasPrefix(term, "pri:"):
+		priority := strings.ToUpper(term[4:])
+		return func(task Task) bool { return task.Priority == priority }, nil
+	}
+
+	if match := dueComparison.FindStringSubmatch(term); match != nil {
+		date, err := parseQueryDate(match[2], now)
+		if err != nil {
+			return nil, err
+		}
+
+		// DueBetween is exclusive, so move the bounds by a day for the inclusive operators
+		day := 24 * time.Hour
+		var never time.Time
+		forever := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+		switch match[1] {
+		case "<":
+			return DueBetween(never, date), nil
+		case "<=":
+			return DueBetween(never, date.Add(day)), nil
+		case ">":
+			return DueBetween(date, forever), nil
+		default:
+			return DueBetween(date.Add(-day), forever), nil
+		}
+	}
+
+	if i := strings.Index(term, ":"); i > 0 && i < len(term) - 1 {
+		return KeyEquals(term[:i], term[i + 1:]), nil
+	}
+
+	return TextMatches(regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))), nil
+}
+
+// parseQueryDate parses YYYY-MM-DD, today, tomorrow or a day offset (7d, -3d, 2w) relative to today
+func parseQueryDate(raw string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch raw {
+	case "today":
+		return today, nil
+	case "tomorrow", "tom":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if date, err := time.Parse(dateLayout, raw); err == nil {
+		return date, nil
+	}
+
+	negative := strings.HasPrefix(raw, "-")
+	offset, err := ParseDuration(strings.TrimPrefix(raw, "-"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s", raw)
+	}
+
+	if negative {
+		offset = -offset
+	}
+
+	return today.Add(offset), nil
+}
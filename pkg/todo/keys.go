@@ -0,0 +1,89 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"strings"
+)
+
+// Value returns the value of the first key:value pair in the description with the provided key or "" if it isn't set
+func (t Task) Value(key string) string {
+	prefix := key + ":"
+
+	for _, field := range strings.Fields(t.Description) {
+		if strings.HasPrefix(field, prefix) && len(field) > len(prefix) {
+			return field[len(prefix):]
+		}
+	}
+
+	return ""
+}
+
+// SetValue replaces the value of key in the description (appending the pair if it isn't present) and reparses the task
+func (t *Task) SetValue(key, value string) {
+	prefix := key + ":"
+	found := false
+
+	fields := strings.Fields(t.Description)
+	for i, field := range fields {
+		if strings.HasPrefix(field, prefix) && len(field) > len(prefix) {
+			fields[i] = prefix + value
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		fields = append(fields, prefix + value)
+	}
+
+	t.Description = strings.Join(fields, " ")
+	t.refresh()
+}
+
+// RemoveValue removes all key:value pairs with the provided key from the description and reparses the task
+func (t *Task) RemoveValue(key string) {
+	prefix := key + ":"
+	var kept []string
+
+	for _, field := range strings.Fields(t.Description) {
+		if strings.HasPrefix(field, prefix) && len(field) > len(prefix) {
+			continue
+		}
+
+		kept = append(kept, field)
+	}
+
+	t.Description = strings.Join(kept, " ")
+	t.refresh()
+}
+
+// refresh recalculates every field derived from the description (due date, hash, etc.)
+func (t *Task) refresh() {
+	deleted := t.Deleted
+	*t = ParseTask(t.String())
+	t.Deleted = deleted
+}
+
+// Projects returns all +project tags in the description
+func (t Task) Projects() []string {
+	return t.tagsWithPrefix("+")
+}
+
+// Contexts returns all @context tags in the description
+func (t Task) Contexts() []string {
+	return t.tagsWithPrefix("@")
+}
+
+func (t Task) tagsWithPrefix(prefix string) []string {
+	var tags []string
+
+	for _, field := range strings.Fields(t.Description) {
+		if strings.HasPrefix(field, prefix) && len(field) > len(prefix) {
+			tags = append(tags, field)
+		}
+	}
+
+	return tags
+}
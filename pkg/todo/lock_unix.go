@@ -0,0 +1,33 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package todo
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockFile waits up to five seconds for an exclusive advisory lock
+func lockFile(file *os.File) error {
+	var err error
+
+	for i := 0; i < 50; i++ {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX | syscall.LOCK_NB)
+		if err != syscall.EWOULDBLOCK {
+			return err
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// A single entry in the result of a three way merge.
+// If Conflict is false, Task holds the merged task. Otherwise Ours and Theirs hold both sides (either may be nil if that side deleted the task).
+type MergeResult struct {
+	Task     Task
+	Conflict bool
+	Base     *Task
+	Ours     *Task
+	Theirs   *Task
+}
+
+// Identity returns the key used to match up the same task across different copies of a file.
+// Tasks with an id: key are matched by it, all other tasks are matched by a hash of their description so that changes in completion status or priority are still recognized as edits.
+func (t Task) Identity() string {
+	if id := t.Value("id"); id != "" {
+		return "id:" + id
+	}
+
+	hash := sha256.Sum256([]byte(t.Description))
+	return hex.EncodeToString(hash[:])
+}
+
+// identify maps every task to a unique identity, disambiguating duplicate descriptions by their occurrence
+func identify(tasks []Task) ([]string, map[string]Task) {
+	keys := make([]string, 0, len(tasks))
+	found := make(map[string]Task)
+	seen := make(map[string]int)
+
+	for _, task := range tasks {
+		key := task.Identity()
+		seen[key]++
+		if seen[key] > 1 {
+			key = fmt.Sprintf("%s#%d", key, seen[key])
+		}
+
+		keys = append(keys, key)
+		found[key] = task
+	}
+
+	return keys, found
+}
+
+// Merge performs a three way merge of two modified copies of a task list.
+// Changes made on only one side (additions, completions, edits and deletions) are applied automatically. Tasks changed differently on both sides are returned as conflicts.
+// The result keeps the order of ours with any tasks added by theirs appended at the end.
+func Merge(base, ours, theirs []Task) []MergeResult {
+	_, baseTasks := identify(base)
+	ourKeys, ourTasks := identify(ours)
+	theirKeys, theirTasks := identify(theirs)
+
+	var results []MergeResult
+
+	// Walk ours first to preserve its order, then pick up anything which only exists in theirs.
+	// Tasks deleted by ours but edited by theirs are picked up here too, so they can be reported as conflicts.
+	order := append([]string{}, ourKeys...)
+	for _, key := range theirKeys {
+		if _, ok := ourTasks[key]; !ok {
+			order = append(order, key)
+		}
+	}
+
+	for _, key := range order {
+		b := lookup(baseTasks, key)
+		o := lookup(ourTasks, key)
+		t := lookup(theirTasks, key)
+
+		result := mergeOne(b, o, t)
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+
+	return results
+}
+
+func lookup(tasks map[string]Task, key string) *Task {
+	if task, ok := tasks[key]; ok {
+		return &task
+	}
+
+	return nil
+}
+
+func sameTask(lhs, rhs *Task) bool {
+	if lhs == nil || rhs == nil {
+		return lhs == rhs
+	}
+
+	return lhs.String() == rhs.String()
+}
+
+// mergeOne decides what happens to a single task. A nil return means the task was deleted.
+func mergeOne(b, o, t *Task) *MergeResult {
+	var winner *Task
+
+	if sameTask(o, t) {
+		// Both sides agree (including both deleting the task)
+		winner = o
+	} else if sameTask(b, o) {
+		// Only theirs changed the task
+		winner = t
+	} else if sameTask(b, t) {
+		// Only ours changed the task
+		winner = o
+	} else {
+		return &MergeResult{ Conflict: true, Base: b, Ours: o, Theirs: t }
+	}
+
+	if winner == nil {
+		return nil
+	}
+
+	return &MergeResult{ Task: *winner }
+}
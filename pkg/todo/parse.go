@@ -4,11 +4,12 @@
 package todo
 
 import (
+	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
-	"fmt"
-	"regexp"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,6 +26,10 @@ type Task struct {
 	// Data           map[string]string	// All key value pairs in the task
 	Deleted        bool			// If the task was deleted (exclude the task from the list)
 	Hash           string		// Unique identifier for this task
+	ID             string		// Value of the id: key, used by other tasks to depend on this one
+	Dependencies   []string		// IDs from all dep: keys which must be completed before this task can start
+	Blocked        bool			// If any dependency is still open (calculated across the whole list by UpdateBlocked)
+	Note           string		// Value of the note: key, the filename of a Markdown note linked to this task
 }
 
 var EmptyDate = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -53,7 +58,7 @@ func (t Task) String() string {
 	}
 
 	if t.Priority != "" {
-		priority = fmt.Sprintf("(%s) ", t.Priority)
+		priority = "(" + t.Priority + ") "
 	}
 
 	// Check completion and creation
@@ -74,43 +79,108 @@ func (t Task) String() string {
 }
 
 func format(d time.Time) string {
-	return fmt.Sprintf("%d-%02d-%02d", d.Year(), d.Month(), d.Day())
+	// Equivalent to fmt.Sprintf("%d-%02d-%02d") without the cost of fmt, which adds up on large files
+	year, month, day := d.Date()
+
+	buf := strconv.AppendInt(make([]byte, 0, 10), int64(year), 10)
+	buf = append(buf, '-', byte('0' + month / 10), byte('0' + month % 10), '-', byte('0' + day / 10), byte('0' + day % 10))
+
+	return string(buf)
 }
 
 func ParseAll(contents string) []Task {
+	return ParseDocument(contents).Tasks
+}
+
+// ReadTasks is ParseAll but reads the tasks from r
+func ReadTasks(r io.Reader) ([]Task, error) {
 	var tasks []Task
 
-	// Handles newlines on Windows
-	contents = strings.ReplaceAll(contents, "\r", "")
-	lines := strings.Split(contents, "\n")
+	dec := NewDecoder(r)
+	for {
+		task, err := dec.Next()
+		if err == io.EOF {
+			return tasks, nil
+		} else if err != nil {
+			return nil, err
+		}
 
-	for _, line := range lines {
-		task := ParseTask(line)
-		if task.Description != "" {
-			tasks = append(tasks, ParseTask(line))
+		tasks = append(tasks, task)
+	}
+}
+
+// WriteTasks writes every task which isn't deleted to w, one per line
+func WriteTasks(w io.Writer, tasks []Task) error {
+	writer := bufio.NewWriterSize(w, 64 * 1024)
+	enc := NewEncoder(writer)
+
+	for _, task := range tasks {
+		if err := enc.Encode(task); err != nil {
+			return err
 		}
 	}
 
-	return tasks
+	return writer.Flush()
+}
+
+const dateLayout = "2006-01-02"
+
+// isDate returns true if s starts with something shaped like a date (0000-00-00)
+func isDate(s string) bool {
+	if len(s) < len(dateLayout) {
+		return false
+	}
+
+	for i := 0; i < len(dateLayout); i++ {
+		if dateLayout[i] == '-' {
+			if s[i] != '-' {
+				return false
+			}
+		} else if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPriority returns true if s starts with a priority ((A) through (Z))
+func isPriority(s string) bool {
+	return len(s) >= 3 && s[0] == '(' && s[1] >= 'A' && s[1] <= 'Z' && s[2] == ')'
+}
+
+// findDue returns the date of the first due:0000-00-00 in s or "" if there isn't one
+func findDue(s string) string {
+	for {
+		i := strings.Index(s, "due:")
+		if i == -1 {
+			return ""
+		}
+
+		s = s[i + 4:]
+		if isDate(s) {
+			return s[:len(dateLayout)]
+		}
+	}
 }
 
 // Formatting rules can be found at https://github.com/todotxt/todo.txt
 func ParseTask(raw string) Task {
+	task, _ := parseTask(raw)
+	return task
+}
+
+// parseTask parses a single line in one pass and also returns its serialization, which is needed for the hash anyway
+func parseTask(raw string) (Task, string) {
 	// completion creation description description description+tag @context due:YYYY-MM-DD
 	// (A) 2020-07-02 2020-07-01 task description goes here +tag @context due:2020-07-02
 
 	var task Task
 
 	if len(raw) == 0 {
-		return task
+		return task, ""
 	}
 
-	dateFormat := "[0-9]{4}-[0-9]{2}-[0-9]{2}"
-	dateRegex := regexp.MustCompile("^" + dateFormat)	// 0000-00-00
-	dueRegex := regexp.MustCompile("due:" + dateFormat)	// due:0000-00-00
-	priorityRegex := regexp.MustCompile("^\\([A-Z]\\)")	// ([A-Z])
-	dateLayout := "2006-01-02"
-
 	// Parse completion status
 	// If the task is completed, mark it as such and remove the "x " prefix
 	if strings.HasPrefix(raw, "x ") {
@@ -118,25 +188,31 @@ func ParseTask(raw string) Task {
 		raw = raw[2:]
 	}
 
+	// Nothing but whitespace is left (i.e. "x ")
+	if strings.TrimSpace(raw) == "" {
+		return task, ""
+	}
+
 	// Parse priority
 	// If the next field in the string looks like a priority, pop and save it
-	priority := strings.Fields(raw)[0]
-	if priorityRegex.MatchString(priority) {
+	if isPriority(raw) {
+		priority := raw
+		if end := strings.IndexAny(raw, " \t"); end != -1 {
+			priority = raw[:end]
+		}
+
 		task.Priority = strings.ReplaceAll(priority, "(", "")
 		task.Priority = strings.ReplaceAll(task.Priority, ")", "")
 
 		// Remove the priority and trailing space
-		remove := len(priority) + 1
-		raw = raw[remove:]
+		raw = trimPrefix(raw, len(priority) + 1)
 	}
 
 	// Parse completion and creation dates
 	for i := 0; i <= 1; i++ {
-		// Returns the date or "" if nothing was found
-		date := dateRegex.FindString(raw)
-		if date != "" {
-			remove := len(date) + 1
-			raw = raw[remove:]
+		if isDate(raw) {
+			date := raw[:len(dateLayout)]
+			raw = trimPrefix(raw, len(date) + 1)
 
 			if i == 0 {
 				task.CompletionDate, _ = time.Parse(dateLayout, date)
@@ -156,17 +232,40 @@ func ParseTask(raw string) Task {
 	task.Description = raw
 
 	// Check for a due date
-	due := dueRegex.FindString(raw)
-	due = strings.ReplaceAll(due, "due:", "")
-	task.DueDate, _ = time.Parse(dateLayout, due)
+	task.DueDate, _ = time.Parse(dateLayout, findDue(raw))
+
+	// Check for dependency information and notes
+	for _, field := range strings.Fields(raw) {
+		if task.ID == "" && strings.HasPrefix(field, "id:") && len(field) > 3 {
+			task.ID = field[3:]
+		} else if task.Note == "" && strings.HasPrefix(field, "note:") && len(field) > 5 {
+			task.Note = field[5:]
+		} else if strings.HasPrefix(field, "dep:") {
+			for _, dep := range strings.Split(field[4:], ",") {
+				if dep != "" {
+					task.Dependencies = append(task.Dependencies, dep)
+				}
+			}
+		}
+	}
 
 	task.Deleted = false
 
 	// Calculate hash
-	hash := sha256.Sum256([]byte(task.String()))
+	serialized := task.String()
+	hash := sha256.Sum256([]byte(serialized))
 	task.Hash = hex.EncodeToString(hash[:])
 
-	return task
+	return task, serialized
+}
+
+// trimPrefix removes the first n bytes of s or returns "" if s is shorter
+func trimPrefix(s string, n int) string {
+	if n >= len(s) {
+		return ""
+	}
+
+	return s[n:]
 }
 
 func SortByDate(raw []Task) []Task {
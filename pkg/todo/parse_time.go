@@ -1,18 +1,36 @@
 package todo
 import (
+	"fmt"
 	"time"
 	"log"
+	"strconv"
 	"strings"
 )
 
+// Keys whose values ParseDates converts from relative dates
+var dateKeys = []string { "due", "followup", "scheduled" }
+
+// ParseDates rewrites relative dates (today, tomorrow/tom and weekdays like fri) in due:, followup: and scheduled: keys to YYYY-MM-DD
 func ParseDates(raw string) string {
 	n := time.Now()
 	original := raw
-	
+
+	for _, key := range dateKeys {
+		raw = parseRelativeDates(raw, key + ":", n)
+	}
+
+	if original != raw {
+		log.Printf("Rewrote task from \"%s\" to \"%s\"", original, raw)
+	}
+
+	return raw
+}
+
+func parseRelativeDates(raw, key string, n time.Time) string {
 	// Simple cases
-	raw = replaceRelativeDate(raw, "due:today", n)
-	raw = replaceRelativeDate(raw, "due:tomorrow", n.AddDate(0, 0, 1))
-	raw = replaceRelativeDate(raw, "due:tom", n.AddDate(0, 0, 1))
+	raw = replaceRelativeDate(raw, key, "today", n)
+	raw = replaceRelativeDate(raw, key, "tomorrow", n.AddDate(0, 0, 1))
+	raw = replaceRelativeDate(raw, key, "tom", n.AddDate(0, 0, 1))
 
 	/*
 		Relative dates are harder - there doesn't seem to be a way to convert the string "Monday" into a Time object
@@ -24,7 +42,7 @@ func ParseDates(raw string) string {
 	*/
 	prefixes := []string { "sun", "mon", "tue", "wed", "thu", "fri", "sat" }
 	for _, day := range prefixes {
-		needle := "due:" + day
+		needle := key + day
 
 		if strings.Contains(strings.ToLower(raw), needle) {
 			for i := 1; i <= 7; i++ {
@@ -33,22 +51,19 @@ func ParseDates(raw string) string {
 				found = strings.ToLower(found)
 
 				if strings.HasPrefix(found, day) {
-					raw = replaceRelativeDate(raw, needle, added)
+					raw = replaceRelativeDate(raw, key, day, added)
 				}
 			}
 		}
 	}
 
-	if original != raw {
-		log.Printf("Rewrote task from \"%s\" to \"%s\"", original, raw)
-	}
-
 	return raw
 }
 
-func replaceRelativeDate(haystack, needle string, date time.Time) string {
+func replaceRelativeDate(haystack, key, relative string, date time.Time) string {
+	needle := key + relative
 	if strings.Contains(haystack, needle) {
-		return strings.ReplaceAll(haystack, needle, "due:" + formatYMD(date))
+		return strings.ReplaceAll(haystack, needle, key + formatYMD(date))
 	}
 
 	return haystack
@@ -56,4 +71,38 @@ func replaceRelativeDate(haystack, needle string, date time.Time) string {
 
 func formatYMD(date time.Time) string {
 	return date.Format("2006-01-02")
-}
\ No newline at end of file
+}
+
+// ParseDuration extends time.ParseDuration with days (d) and weeks (w), i.e. "1w", "3d" or "1h30m"
+func ParseDuration(raw string) (time.Duration, error) {
+	if strings.HasSuffix(raw, "d") || strings.HasSuffix(raw, "w") {
+		count, err := strconv.Atoi(raw[:len(raw) - 1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", raw)
+		}
+
+		days := count
+		if strings.HasSuffix(raw, "w") {
+			days *= 7
+		}
+
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(raw)
+}
+
+// FormatDuration formats a duration to the minute without any trailing zero units (i.e. "1h30m" instead of "1h30m0s")
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	} else if minutes == 0 {
+		return fmt.Sprintf("%dh", hours)
+	}
+
+	return fmt.Sprintf("%dh%dm", hours, minutes)
+}
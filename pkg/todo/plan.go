@@ -0,0 +1,115 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"sort"
+	"time"
+)
+
+// Estimate returns the value of the est: key (i.e. 30m or 2h) or false if it is missing or invalid
+func (t Task) Estimate() (time.Duration, bool) {
+	raw := t.Value("est")
+	if raw == "" {
+		return 0, false
+	}
+
+	estimate, err := ParseDuration(raw)
+	if err != nil || estimate <= 0 {
+		return 0, false
+	}
+
+	return estimate, true
+}
+
+// Slot is a single entry of a plan's timeline, either a task (Task is its index) or an event (Task is -1)
+type Slot struct {
+	Start time.Time
+	End   time.Time
+	Task  int
+	Event Event
+}
+
+// Plan is the result of PlanDay
+type Plan struct {
+	Timeline    []Slot		// Tasks and events in chronological order
+	Unplanned   []int		// Tasks which didn't fit
+	Unestimated []int		// Tasks without an est: key
+}
+
+// PlanDay fills the time between start and end with tasks in the provided order, around the events (including every occurrence of recurring events).
+// Each task is placed in the earliest gap it fits in, so a small task can still fit after a large one was skipped.
+func PlanDay(tasks []Task, order []int, events []Event, start, end time.Time) Plan {
+	var plan Plan
+
+	var occurrences []Event
+	for _, event := range events {
+		occurrences = append(occurrences, event.Occurrences(start, end)...)
+	}
+
+	for _, event := range occurrences {
+		// Only the part of the event during the planned time counts
+		slot := Slot{ Start: event.Start, End: event.End, Task: -1, Event: event }
+		if slot.Start.Before(start) {
+			slot.Start = start
+		}
+		if slot.End.After(end) {
+			slot.End = end
+		}
+
+		plan.Timeline = append(plan.Timeline, slot)
+	}
+	sortSlots(plan.Timeline)
+
+	for _, i := range order {
+		estimate, ok := tasks[i].Estimate()
+		if !ok {
+			plan.Unestimated = append(plan.Unestimated, i)
+			continue
+		}
+
+		placed := false
+		free := start
+		for j := 0; j <= len(plan.Timeline); j++ {
+			gapEnd := end
+			if j < len(plan.Timeline) {
+				gapEnd = plan.Timeline[j].Start
+			}
+
+			if !free.Add(estimate).After(gapEnd) {
+				plan.Timeline = append(plan.Timeline, Slot{ Start: free, End: free.Add(estimate), Task: i })
+				sortSlots(plan.Timeline)
+				placed = true
+				break
+			}
+
+			if j < len(plan.Timeline) && plan.Timeline[j].End.After(free) {
+				free = plan.Timeline[j].End
+			}
+		}
+
+		if !placed {
+			plan.Unplanned = append(plan.Unplanned, i)
+		}
+	}
+
+	return plan
+}
+
+func sortSlots(slots []Slot) {
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+}
+
+// Planned returns the time taken up by tasks and events
+func (p Plan) Planned() (tasks time.Duration, events time.Duration) {
+	for _, slot := range p.Timeline {
+		if slot.Task == -1 {
+			events += slot.End.Sub(slot.Start)
+		} else {
+			tasks += slot.End.Sub(slot.Start)
+		}
+	}
+
+	return tasks, events
+}
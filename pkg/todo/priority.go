@@ -0,0 +1,74 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Raises a task's priority from From to To once it is due within Days days. An empty From matches tasks without a priority.
+type EscalationRule struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"`
+}
+
+var validPriority = regexp.MustCompile("^[A-Z]$")
+
+// ValidPriority returns true if the provided string is a single uppercase letter
+func ValidPriority(priority string) bool {
+	return validPriority.MatchString(priority)
+}
+
+// Validate checks that both priorities of a rule are single uppercase letters (From may also be empty)
+func (r EscalationRule) Validate() error {
+	if !ValidPriority(r.To) {
+		return fmt.Errorf("invalid priority %q, expected a letter from A to Z", r.To)
+	}
+
+	if r.From != "" && !ValidPriority(r.From) {
+		return fmt.Errorf("invalid priority %q, expected a letter from A to Z", r.From)
+	}
+
+	return nil
+}
+
+// SetPriority changes (or removes, if priority is "") the priority of a task and recalculates its hash
+func (t *Task) SetPriority(priority string) {
+	t.Priority = strings.ToUpper(priority)
+	t.refresh()
+}
+
+// Escalate applies the escalation rules to an open task with a due date and returns the resulting priority.
+// Rules are applied repeatedly so that a chain like C->B at 3 days and B->A at 0 days takes a task due today from C to A.
+func Escalate(task Task, rules []EscalationRule, now time.Time) string {
+	priority := task.Priority
+	if task.Completed || task.DueDate.IsZero() {
+		return priority
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	remaining := int(task.DueDate.Sub(today).Hours() / 24)
+
+	// Every rule can only be applied once which prevents loops in misconfigured rules
+	applied := make([]bool, len(rules))
+	for changed := true; changed; {
+		changed = false
+
+		for i, rule := range rules {
+			if applied[i] || rule.From != priority || remaining > rule.Days {
+				continue
+			}
+
+			priority = rule.To
+			applied[i] = true
+			changed = true
+		}
+	}
+
+	return priority
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"strconv"
+	"time"
+)
+
+// Snooze moves the due date to target(base) and the threshold date (t:) by the same amount, then increments snoozed:.
+// base is the due date or today, whichever is later, so snoozing never leaves a task in the past unless the target is absolute.
+// Tasks with a threshold date but no due date have their threshold moved instead, tasks with neither get a due date.
+// Returns false without changing the task if neither date would move.
+func (t *Task) Snooze(target func(base time.Time) time.Time, now time.Time) bool {
+	before := t.Value("due") + " " + t.Value("t")
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	threshold, _ := time.Parse(dateLayout, t.Value("t"))
+
+	later := func(date time.Time) time.Time {
+		if date.Before(today) {
+			return today
+		}
+		return date
+	}
+
+	switch {
+	case !t.DueDate.IsZero():
+		due := target(later(t.DueDate))
+		if !threshold.IsZero() {
+			t.SetValue("t", format(threshold.Add(due.Sub(t.DueDate))))
+		}
+		t.SetValue("due", format(due))
+
+	case !threshold.IsZero():
+		t.SetValue("t", format(target(later(threshold))))
+
+	default:
+		t.SetValue("due", format(target(today)))
+	}
+
+	if t.Value("due") + " " + t.Value("t") == before {
+		return false
+	}
+
+	snoozed, _ := strconv.Atoi(t.Value("snoozed"))
+	t.SetValue("snoozed", strconv.Itoa(snoozed + 1))
+	return true
+}
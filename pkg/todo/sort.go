@@ -0,0 +1,101 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// compareDates orders missing dates after every real date
+func compareDates(lhs, rhs time.Time) int {
+	switch {
+	case lhs.Equal(rhs):
+		return 0
+	case lhs.IsZero():
+		return 1
+	case rhs.IsZero():
+		return -1
+	case lhs.Before(rhs):
+		return -1
+	}
+	return 1
+}
+
+// SortKey is an additional sort key for SortIndexes
+type SortKey struct {
+	Name    string
+	Compare func(a, b Task) int		// Negative if a sorts first, positive if b does and 0 if they are equal
+}
+
+// sortKeys compare two tasks, returning a negative number if a sorts first, a positive number if b does or 0 if they are equal
+var sortKeys = map[string]func(a, b Task) int {
+	"due": func(a, b Task) int { return compareDates(a.DueDate, b.DueDate) },
+	"created": func(a, b Task) int { return compareDates(a.CreationDate, b.CreationDate) },
+	"completed": func(a, b Task) int { return compareDates(a.CompletionDate, b.CompletionDate) },
+	"priority": func(a, b Task) int {
+		// Tasks without a priority sort last
+		lhs, rhs := a.Priority, b.Priority
+		if lhs == "" {
+			lhs = "~"
+		}
+		if rhs == "" {
+			rhs = "~"
+		}
+		return strings.Compare(lhs, rhs)
+	},
+	"description": func(a, b Task) int { return strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description)) },
+}
+
+// SortIndexes sorts the indexes of tasks by a comma separated list of sort keys (file, due, created, completed, priority, description
+// or the name of any of the custom keys). Later keys break ties of earlier keys, a key prefixed with - is reversed and tasks which are
+// equal on every key keep their order. The file key sorts by the position of the task in tasks.
+func SortIndexes(tasks []Task, indexes []int, keys string, custom ...SortKey) error {
+	var compare []func(a, b int) int
+
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, "-")
+
+		byTask, ok := sortKeys[name]
+		for _, key := range custom {
+			if key.Name == name {
+				byTask, ok = key.Compare, true
+			}
+		}
+
+		var f func(a, b int) int
+		if name == "file" {
+			f = func(a, b int) int { return a - b }
+		} else if ok {
+			f = func(a, b int) int { return byTask(tasks[a], tasks[b]) }
+		} else {
+			return fmt.Errorf("unknown sort key %s", key)
+		}
+
+		if strings.HasPrefix(key, "-") {
+			forward := f
+			f = func(a, b int) int { return -forward(a, b) }
+		}
+
+		compare = append(compare, f)
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		for _, f := range compare {
+			if result := f(indexes[i], indexes[j]); result != 0 {
+				return result < 0
+			}
+		}
+		return false
+	})
+
+	return nil
+}
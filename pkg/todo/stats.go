@@ -0,0 +1,165 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+// Counts of tasks created and completed in a single day, week or month
+type PeriodCount struct {
+	Created   int `json:"created"`
+	Completed int `json:"completed"`
+}
+
+// Task counts for a single project or priority
+type Breakdown struct {
+	Open      int `json:"open"`
+	Completed int `json:"completed"`
+	Overdue   int `json:"overdue"`
+}
+
+type Stats struct {
+	Total              int                     `json:"total"`
+	Open               int                     `json:"open"`
+	Completed          int                     `json:"completed"`
+	Overdue            int                     `json:"overdue"`					// Open tasks which are past their due date
+	CompletedOnTime    int                     `json:"completed_on_time"`
+	CompletedLate      int                     `json:"completed_late"`
+	Cancelled          int                     `json:"cancelled"`				// Tasks with status:cancelled, which only count towards Total and the created counts
+	OnTimeRate         float64                 `json:"on_time_rate"`				// Fraction of completed tasks with a due date that were completed on time
+	AverageLeadTime    float64                 `json:"average_lead_time_days"`	// Average number of days from creation to completion
+	PerDay             map[string]*PeriodCount `json:"per_day"`					// Keyed by YYYY-MM-DD
+	PerWeek            map[string]*PeriodCount `json:"per_week"`				// Keyed by ISO week (YYYY-Www)
+	PerMonth           map[string]*PeriodCount `json:"per_month"`				// Keyed by YYYY-MM
+	ByProject          map[string]*Breakdown   `json:"by_project"`
+	ByPriority         map[string]*Breakdown   `json:"by_priority"`
+}
+
+func weekKey(d time.Time) string {
+	year, week := d.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// count increments the created or completed counter of the day, week and month containing d
+func (s *Stats) count(d time.Time, completed bool) {
+	keys := []string { format(d), weekKey(d), d.Format("2006-01") }
+	periods := []map[string]*PeriodCount { s.PerDay, s.PerWeek, s.PerMonth }
+
+	for i, period := range periods {
+		if period[keys[i]] == nil {
+			period[keys[i]] = &PeriodCount{}
+		}
+
+		if completed {
+			period[keys[i]].Completed++
+		} else {
+			period[keys[i]].Created++
+		}
+	}
+}
+
+func breakdown(m map[string]*Breakdown, key string) *Breakdown {
+	if m[key] == nil {
+		m[key] = &Breakdown{}
+	}
+
+	return m[key]
+}
+
+// ComputeStats analyses the creation, completion and due dates of all provided tasks (typically the main list and the archive)
+func ComputeStats(tasks []Task, now time.Time) Stats {
+	stats := Stats {
+		PerDay: make(map[string]*PeriodCount),
+		PerWeek: make(map[string]*PeriodCount),
+		PerMonth: make(map[string]*PeriodCount),
+		ByProject: make(map[string]*Breakdown),
+		ByPriority: make(map[string]*Breakdown),
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var leadTime time.Duration
+	leadCount := 0
+
+	for _, task := range tasks {
+		if task.Deleted {
+			continue
+		}
+
+		stats.Total++
+
+		if !task.CreationDate.IsZero() {
+			stats.count(task.CreationDate, false)
+		}
+
+		// Cancelled tasks weren't completed, so counting them would skew the on time rate and lead time
+		if task.Value("status") == Cancelled {
+			stats.Cancelled++
+			continue
+		}
+
+		if !task.CompletionDate.IsZero() {
+			stats.count(task.CompletionDate, true)
+
+			if !task.CreationDate.IsZero() {
+				leadTime += task.CompletionDate.Sub(task.CreationDate)
+				leadCount++
+			}
+
+			if !task.DueDate.IsZero() {
+				if task.CompletionDate.After(task.DueDate) {
+					stats.CompletedLate++
+				} else {
+					stats.CompletedOnTime++
+				}
+			}
+		}
+
+		overdue := !task.Completed && !task.DueDate.IsZero() && task.DueDate.Before(today)
+
+		// Update every breakdown this task belongs to
+		priority := task.Priority
+		if priority == "" {
+			priority = "none"
+		}
+
+		groups := []*Breakdown { breakdown(stats.ByPriority, priority) }
+		for _, project := range task.Projects() {
+			groups = append(groups, breakdown(stats.ByProject, project))
+		}
+
+		for _, group := range groups {
+			if task.Completed {
+				group.Completed++
+			} else {
+				group.Open++
+			}
+
+			if overdue {
+				group.Overdue++
+			}
+		}
+
+		if task.Completed {
+			stats.Completed++
+		} else {
+			stats.Open++
+		}
+
+		if overdue {
+			stats.Overdue++
+		}
+	}
+
+	if leadCount > 0 {
+		stats.AverageLeadTime = leadTime.Hours() / 24 / float64(leadCount)
+	}
+
+	if judged := stats.CompletedOnTime + stats.CompletedLate; judged > 0 {
+		stats.OnTimeRate = float64(stats.CompletedOnTime) / float64(judged)
+	}
+
+	return stats
+}
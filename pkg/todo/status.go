@@ -0,0 +1,100 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+// Cancelled is the status of tasks which won't be done. They are archived separately and excluded from on time statistics.
+const Cancelled = "cancelled"
+
+// Workflow is a state machine for the status: key
+type Workflow struct {
+	States      []string            `json:"states"`			// Every valid status, the first is the status of tasks without a status: key
+	Closed      []string            `json:"closed"`			// Statuses which complete a task, the first is used by do
+	Transitions map[string][]string `json:"transitions"`	// Statuses which may follow each status. Any status may follow one which isn't listed.
+}
+
+var DefaultWorkflow = Workflow {
+	States: []string{ "todo", "doing", "waiting", "done", Cancelled },
+	Closed: []string{ "done", Cancelled },
+	Transitions: map[string][]string {
+		"todo": { "doing", "waiting", "done", Cancelled },
+		"doing": { "todo", "waiting", "done", Cancelled },
+		"waiting": { "todo", "doing", "done", Cancelled },
+		"done": { "todo" },
+		Cancelled: { "todo" },
+	},
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Status returns the value of the task's status: key. Tasks without one are in the first closed status if they are completed
+// and in the initial status otherwise.
+func (w Workflow) Status(task Task) string {
+	if status := task.Value("status"); status != "" {
+		return status
+	}
+
+	if task.Completed && len(w.Closed) > 0 {
+		return w.Closed[0]
+	} else if len(w.States) > 0 {
+		return w.States[0]
+	}
+
+	return ""
+}
+
+// Valid returns true if status is one of the workflow's states
+func (w Workflow) Valid(status string) bool {
+	return contains(w.States, status)
+}
+
+// IsClosed returns true if tasks in this status are completed
+func (w Workflow) IsClosed(status string) bool {
+	return contains(w.Closed, status)
+}
+
+// CanTransition returns true if a task may move from one status to the other. Staying in the same status is always allowed.
+func (w Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+
+	allowed, ok := w.Transitions[from]
+	return !ok || contains(allowed, to)
+}
+
+// Transition moves a task to a new status, marking it completed (with a completion date if it has a creation date) when the status is closed
+func (w Workflow) Transition(task *Task, status string, now time.Time) error {
+	if !w.Valid(status) {
+		return fmt.Errorf("unknown status %s", status)
+	}
+
+	current := w.Status(*task)
+	if !w.CanTransition(current, status) {
+		return fmt.Errorf("a task can't move from %s to %s", current, status)
+	}
+
+	closed := w.IsClosed(status)
+	if closed && !task.Completed && !task.CreationDate.IsZero() {
+		task.CompletionDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	} else if !closed {
+		task.CompletionDate = EmptyDate
+	}
+	task.Completed = closed
+
+	task.SetValue("status", status)
+	return nil
+}
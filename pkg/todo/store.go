@@ -0,0 +1,78 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrModified is returned by Lock if the task list was changed by another process since it was loaded
+var ErrModified = errors.New("task list was modified since it was loaded")
+
+// Store loads and saves a task list along with its archive of completed tasks
+type Store interface {
+	// Load returns all tasks in the task list
+	Load() ([]Task, error)
+
+	// Save replaces the task list and any sidecars as a single operation, skipping any tasks marked as deleted
+	Save(tasks []Task, sidecars ...Sidecar) error
+
+	// LoadSidecar returns all tasks in the named sidecar. A missing sidecar is not an error.
+	LoadSidecar(name string) ([]Task, error)
+
+	// LoadArchive returns all archived tasks. A missing archive is not an error.
+	LoadArchive() ([]Task, error)
+
+	// SaveArchive replaces the archive, skipping any tasks marked as deleted
+	SaveArchive(tasks []Task) error
+
+	// SaveAll replaces both the task list and the archive as a single operation
+	SaveAll(tasks, archived []Task) error
+
+	// Archive moves all completed tasks to the archive, saves both along with any sidecars and returns the remaining tasks
+	Archive(tasks []Task, sidecars ...Sidecar) ([]Task, error)
+
+	// Backup saves a copy of the current task list which can be used to undo the next write
+	Backup() error
+
+	// BackupArchive is Backup for the archive. A missing archive is not an error.
+	BackupArchive() error
+
+	// Lock prevents other processes from modifying the task list until the returned function is called
+	Lock() (func() error, error)
+}
+
+// Sidecar is an extra task list kept next to the task list, such as the trash
+type Sidecar struct {
+	Name  string
+	Tasks []Task
+}
+
+// formatTasks serializes all tasks which aren't deleted, one per line
+func formatTasks(tasks []Task) string {
+	var builder strings.Builder
+
+	// Writing to a strings.Builder can't fail
+	WriteTasks(&builder, tasks)
+
+	return builder.String()
+}
+
+// splitCompleted separates completed tasks from the rest, skipping deleted tasks
+func splitCompleted(tasks []Task) ([]Task, []Task) {
+	var remaining, completed []Task
+
+	for _, task := range tasks {
+		if task.Deleted {
+			continue
+		} else if task.Completed {
+			completed = append(completed, task)
+		} else {
+			remaining = append(remaining, task)
+		}
+	}
+
+	return remaining, completed
+}
@@ -0,0 +1,299 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileStore keeps tasks in a todo.txt file with completed tasks archived to a second file
+type FileStore struct {
+	Filename        string
+	ArchiveFilename string		// Defaults to Filename with its extension replaced by -done.txt
+	BackupFilename  string		// Defaults to Filename with .bak appended
+
+	// Called once with every file an operation is about to write. Returning an error aborts the whole operation.
+	BeforeWrite func(writes []FileWrite) error
+
+	loaded  time.Time			// Modification time of Filename when it was last loaded
+	lock    *os.File
+}
+
+func NewFileStore(filename string) *FileStore {
+	return &FileStore {
+		Filename: filename,
+		ArchiveFilename: SidecarFilename(filename, "done"),
+		BackupFilename: filename + ".bak",
+	}
+}
+
+func readTasks(filename string) ([]Task, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadTasks(file)
+}
+
+// readDocument returns the document in filename or an empty document if it doesn't exist
+func readDocument(filename string) (*Document, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return ParseDocument(""), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadDocument(file)
+}
+
+// write saves every file after BeforeWrite agreed to all of them. Every file is rendered to a temporary file next to it
+// before any of them is renamed over the original, so a failure can't leave the files half written or out of sync.
+func (s *FileStore) write(writes ...FileWrite) error {
+	if s.BeforeWrite != nil {
+		if err := s.BeforeWrite(writes); err != nil {
+			return err
+		}
+	}
+
+	var temps, targets []string
+	cleanup := func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+	}
+
+	for _, w := range writes {
+		temp, target, err := prepareFile(w.Filename, w.Tasks)
+		if err != nil {
+			cleanup()
+			return err
+		}
+
+		temps = append(temps, temp)
+		targets = append(targets, target)
+	}
+
+	for i, temp := range temps {
+		if err := os.Rename(temp, targets[i]); err != nil {
+			cleanup()
+			return fmt.Errorf("unable to write %s: %w", writes[i].Filename, err)
+		}
+
+		if writes[i].Filename == s.Filename {
+			s.loaded = modTime(s.Filename)
+		}
+	}
+
+	return nil
+}
+
+// prepareFile renders the tasks to a temporary file next to filename (or the file it links to) with the same permissions.
+// Returns the temporary file and the file it should be renamed to.
+func prepareFile(filename string, tasks []Task) (string, string, error) {
+	// Replace the file a symlink points to instead of the symlink itself
+	target := filename
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		target = resolved
+	}
+
+	// Blank lines, comments and line endings of the existing file are kept
+	doc, err := readDocument(target)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to open %s: %w", filename, err)
+	}
+
+	temp, err := writeTemp(filename, target, func(w io.Writer) error {
+		return doc.RenderTo(w, tasks)
+	})
+
+	return temp, target, err
+}
+
+func modTime(filename string) time.Time {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+func (s *FileStore) Load() ([]Task, error) {
+	s.loaded = modTime(s.Filename)
+
+	tasks, err := readTasks(s.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %w", s.Filename, err)
+	}
+
+	return tasks, nil
+}
+
+// Save writes the sidecars before the task list, renaming them all into place only after every file was written
+func (s *FileStore) Save(tasks []Task, sidecars ...Sidecar) error {
+	writes, err := s.sidecarWrites(sidecars)
+	if err != nil {
+		return err
+	}
+
+	return s.write(append(writes, FileWrite{ s.Filename, tasks })...)
+}
+
+// sidecarFilename returns the file holding the named sidecar, which must not be the task list or the archive
+func (s *FileStore) sidecarFilename(name string) (string, error) {
+	filename := SidecarFilename(s.Filename, name)
+	if filename == s.Filename || filename == s.ArchiveFilename {
+		return "", fmt.Errorf("unable to keep the %s file %s next to %s and %s", name, filename, s.Filename, s.ArchiveFilename)
+	}
+
+	return filename, nil
+}
+
+func (s *FileStore) sidecarWrites(sidecars []Sidecar) ([]FileWrite, error) {
+	var writes []FileWrite
+	for _, sidecar := range sidecars {
+		filename, err := s.sidecarFilename(sidecar.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		writes = append(writes, FileWrite{ filename, sidecar.Tasks })
+	}
+
+	return writes, nil
+}
+
+func (s *FileStore) LoadSidecar(name string) ([]Task, error) {
+	filename, err := s.sidecarFilename(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := readTasks(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %w", filename, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *FileStore) LoadArchive() ([]Task, error) {
+	tasks, err := readTasks(s.ArchiveFilename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %w", s.ArchiveFilename, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *FileStore) SaveArchive(tasks []Task) error {
+	return s.write(FileWrite{ s.ArchiveFilename, tasks })
+}
+
+// SaveAll saves the task list and the archive, renaming both into place only after both were written
+func (s *FileStore) SaveAll(tasks, archived []Task) error {
+	return s.write(FileWrite{ s.Filename, tasks }, FileWrite{ s.ArchiveFilename, archived })
+}
+
+func (s *FileStore) Archive(tasks []Task, sidecars ...Sidecar) ([]Task, error) {
+	archived, err := s.LoadArchive()
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := s.sidecarWrites(sidecars)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, completed := splitCompleted(tasks)
+	archived = append(archived, completed...)
+
+	// Archived tasks are marked as deleted instead of removed so the remaining tasks keep their lines in the document
+	kept := make([]Task, len(tasks))
+	for i, task := range tasks {
+		kept[i] = task
+		kept[i].Deleted = kept[i].Deleted || task.Completed
+	}
+
+	// Write the archive and sidecars first so a failure can't lose any tasks
+	writes = append(writes, FileWrite{ s.ArchiveFilename, archived }, FileWrite{ s.Filename, kept })
+	if err := s.write(writes...); err != nil {
+		return nil, err
+	}
+
+	return remaining, nil
+}
+
+func copyFile(source, destination string) error {
+	contents, err := ioutil.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("unable to open %s: %w", source, err)
+	}
+
+	if err := ioutil.WriteFile(destination, contents, 0644); err != nil {
+		return fmt.Errorf("unable to create backup %s: %w", destination, err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) Backup() error {
+	return copyFile(s.Filename, s.BackupFilename)
+}
+
+// BackupArchive copies the archive next to itself with .bak appended
+func (s *FileStore) BackupArchive() error {
+	if _, err := os.Stat(s.ArchiveFilename); os.IsNotExist(err) {
+		return nil
+	}
+
+	return copyFile(s.ArchiveFilename, s.ArchiveFilename + ".bak")
+}
+
+// Lock takes an exclusive lock on Filename.lock which is automatically released when the process exits.
+// If the task list was loaded before locking and has been modified since, ErrModified is returned.
+func (s *FileStore) Lock() (func() error, error) {
+	if s.lock != nil {
+		return nil, fmt.Errorf("%s is already locked", s.Filename)
+	}
+
+	file, err := os.OpenFile(s.Filename + ".lock", os.O_CREATE | os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to lock %s: %w", s.Filename, err)
+	}
+
+	s.lock = file
+
+	unlock := func() error {
+		s.lock = nil
+		unlockFile(file)
+		return file.Close()
+	}
+
+	if !s.loaded.IsZero() && !modTime(s.Filename).Equal(s.loaded) {
+		unlock()
+		return nil, ErrModified
+	}
+
+	return unlock, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"errors"
+	"sync"
+)
+
+// MemoryStore keeps all tasks in memory which is mostly useful for tests
+type MemoryStore struct {
+	Tasks          []Task
+	Archived       []Task
+	Backups        [][]Task		// Every backup made, oldest first
+	ArchiveBackups [][]Task		// Every backup of the archive made, oldest first
+	Sidecars       map[string][]Task
+
+	mutex  sync.Mutex
+	locked bool
+}
+
+func copyTasks(tasks []Task) []Task {
+	return append([]Task{}, tasks...)
+}
+
+func (s *MemoryStore) Load() ([]Task, error) {
+	return copyTasks(s.Tasks), nil
+}
+
+func (s *MemoryStore) Save(tasks []Task, sidecars ...Sidecar) error {
+	s.saveSidecars(sidecars)
+	s.Tasks = ParseAll(formatTasks(tasks))
+	return nil
+}
+
+func (s *MemoryStore) saveSidecars(sidecars []Sidecar) {
+	if s.Sidecars == nil && len(sidecars) > 0 {
+		s.Sidecars = make(map[string][]Task)
+	}
+
+	for _, sidecar := range sidecars {
+		s.Sidecars[sidecar.Name] = ParseAll(formatTasks(sidecar.Tasks))
+	}
+}
+
+func (s *MemoryStore) LoadSidecar(name string) ([]Task, error) {
+	return copyTasks(s.Sidecars[name]), nil
+}
+
+func (s *MemoryStore) LoadArchive() ([]Task, error) {
+	return copyTasks(s.Archived), nil
+}
+
+func (s *MemoryStore) SaveArchive(tasks []Task) error {
+	s.Archived = ParseAll(formatTasks(tasks))
+	return nil
+}
+
+func (s *MemoryStore) SaveAll(tasks, archived []Task) error {
+	s.Save(tasks)
+	return s.SaveArchive(archived)
+}
+
+func (s *MemoryStore) Archive(tasks []Task, sidecars ...Sidecar) ([]Task, error) {
+	s.saveSidecars(sidecars)
+	remaining, completed := splitCompleted(tasks)
+
+	s.Archived = append(s.Archived, ParseAll(formatTasks(completed))...)
+	s.Save(remaining)
+
+	return remaining, nil
+}
+
+func (s *MemoryStore) Backup() error {
+	s.Backups = append(s.Backups, copyTasks(s.Tasks))
+	return nil
+}
+
+func (s *MemoryStore) BackupArchive() error {
+	s.ArchiveBackups = append(s.ArchiveBackups, copyTasks(s.Archived))
+	return nil
+}
+
+func (s *MemoryStore) Lock() (func() error, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.locked {
+		return nil, errors.New("store is already locked")
+	}
+	s.locked = true
+
+	return func() error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.locked = false
+		return nil
+	}, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagSummary counts the tasks tagged with a project or context (or any of its children)
+type TagSummary struct {
+	Tag     string		// Full tag including the prefix, i.e. +work.infra
+	Depth   int			// Number of parents in the hierarchy (+work is 0, +work.infra is 1)
+	Open    int
+	Done    int
+	NextDue time.Time	// Earliest due date of an open task or the zero time
+}
+
+// tagParents returns the tag followed by every parent in a dotted hierarchy (+a.b.c returns +a.b.c, +a.b and +a)
+func tagParents(tag string) []string {
+	parents := []string{ tag }
+
+	for i := len(tag) - 1; i > 1; i-- {
+		if tag[i] == '.' {
+			parents = append(parents, tag[:i])
+		}
+	}
+
+	return parents
+}
+
+// SummarizeTags counts open and completed tasks for every tag starting with prefix ("+" or "@").
+// Hierarchical tags like +work.infra.k8s also count towards +work.infra and +work, which are included even if no task uses them directly.
+// The result is sorted so that every tag is directly followed by its children.
+func SummarizeTags(tasks []Task, prefix string) []TagSummary {
+	summaries := make(map[string]*TagSummary)
+
+	for _, task := range tasks {
+		// A task tagged with +work.a and +work.b is only counted once for +work
+		counted := make(map[string]bool)
+
+		for _, tag := range task.tagsWithPrefix(prefix) {
+			for _, parent := range tagParents(tag) {
+				if counted[parent] {
+					continue
+				}
+				counted[parent] = true
+
+				summary, ok := summaries[parent]
+				if !ok {
+					summary = &TagSummary{ Tag: parent, Depth: strings.Count(parent, ".") }
+					summaries[parent] = summary
+				}
+
+				if task.Completed {
+					summary.Done++
+					continue
+				}
+
+				summary.Open++
+				if !task.DueDate.IsZero() && (summary.NextDue.IsZero() || task.DueDate.Before(summary.NextDue)) {
+					summary.NextDue = task.DueDate
+				}
+			}
+		}
+	}
+
+	ret := make([]TagSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		ret = append(ret, *summary)
+	}
+
+	// Sorting on the segments keeps children directly after their parent (+a.b before +a-c)
+	sort.Slice(ret, func(i, j int) bool {
+		lhs, rhs := strings.Split(ret[i].Tag, "."), strings.Split(ret[j].Tag, ".")
+		for k := 0; k < len(lhs) && k < len(rhs); k++ {
+			if lhs[k] != rhs[k] {
+				return lhs[k] < rhs[k]
+			}
+		}
+		return len(lhs) < len(rhs)
+	})
+
+	return ret
+}
+
+// RenameTag replaces the tag old with new in the description, along with any children in its hierarchy
+// (renaming +work to +job also renames +work.infra to +job.infra). Returns true if the task was changed.
+func (t *Task) RenameTag(old, new string) bool {
+	changed := false
+
+	fields := strings.Fields(t.Description)
+	for i, field := range fields {
+		if field == old {
+			fields[i] = new
+			changed = true
+		} else if strings.HasPrefix(field, old + ".") {
+			fields[i] = new + field[len(old):]
+			changed = true
+		}
+	}
+
+	if changed {
+		t.Description = strings.Join(fields, " ")
+		t.refresh()
+	}
+
+	return changed
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A single tracked time interval. A zero End means the timer is still running.
+type Interval struct {
+	ID    string		// id: of the tracked task
+	Start time.Time
+	End   time.Time
+}
+
+const timestampLayout = time.RFC3339
+
+// Running returns true if the interval hasn't been stopped yet
+func (i Interval) Running() bool {
+	return i.End.IsZero()
+}
+
+// Duration returns the length of the interval, counting running intervals up to now
+func (i Interval) Duration(now time.Time) time.Duration {
+	if i.Running() {
+		return now.Sub(i.Start)
+	}
+
+	return i.End.Sub(i.Start)
+}
+
+// Clip returns the part of the interval which happened after since
+func (i Interval) Clip(since time.Time) Interval {
+	if i.Start.Before(since) {
+		i.Start = since
+	}
+
+	return i
+}
+
+// Log lines have the format "ID START [END]" with timestamps in RFC 3339
+func (i Interval) String() string {
+	line := fmt.Sprintf("%s %s", i.ID, i.Start.Format(timestampLayout))
+	if !i.Running() {
+		line += " " + i.End.Format(timestampLayout)
+	}
+
+	return line
+}
+
+// ParseTimeLog parses the contents of a time tracking log, skipping malformed lines
+func ParseTimeLog(contents string) []Interval {
+	var intervals []Interval
+
+	contents = strings.ReplaceAll(contents, "\r", "")
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var interval Interval
+		var err error
+
+		interval.ID = fields[0]
+		if interval.Start, err = time.Parse(timestampLayout, fields[1]); err != nil {
+			continue
+		}
+
+		if len(fields) > 2 {
+			if interval.End, err = time.Parse(timestampLayout, fields[2]); err != nil {
+				continue
+			}
+		}
+
+		intervals = append(intervals, interval)
+	}
+
+	return intervals
+}
+
+// NextID returns the smallest numeric id: which isn't used by any of the provided task lists
+func NextID(lists ...[]Task) string {
+	used := make(map[string]bool)
+	for _, tasks := range lists {
+		for _, task := range tasks {
+			used[task.ID] = true
+		}
+	}
+
+	for i := 1; ; i++ {
+		id := fmt.Sprintf("%d", i)
+		if !used[id] {
+			return id
+		}
+	}
+}
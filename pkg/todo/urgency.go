@@ -0,0 +1,144 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UrgencyCoefficients weigh the factors of the urgency score. Every factor is scaled to roughly 0-1 before it is multiplied by its coefficient.
+type UrgencyCoefficients struct {
+	Priority float64            `json:"priority"`	// (A) is 1, every lower priority is 0.25 less
+	Due      float64            `json:"due"`		// 1 when due today or earlier, falling to 0.2 two weeks out
+	Overdue  float64            `json:"overdue"`	// Per day past the due date, up to 30 days
+	Age      float64            `json:"age"`		// Days since the creation date divided by 365, up to 1
+	Blocked  float64            `json:"blocked"`	// 1 if the task depends on an open task, so this should be negative
+	Projects map[string]float64 `json:"projects"`	// Added for every project, +work also applies to +work.infra
+}
+
+var DefaultUrgency = UrgencyCoefficients {
+	Priority: 6,
+	Due: 12,
+	Overdue: 0.5,
+	Age: 2,
+	Blocked: -5,
+}
+
+// UrgencyFactor is a single part of an urgency score
+type UrgencyFactor struct {
+	Name   string
+	Detail string		// Why the factor applies, i.e. "due in 3 days"
+	Score  float64
+}
+
+// Urgency scores how soon a task should be worked on and returns the factors the score is made of.
+// Completed tasks always score 0.
+func (c UrgencyCoefficients) Urgency(task Task, now time.Time) (float64, []UrgencyFactor) {
+	if task.Completed {
+		return 0, nil
+	}
+
+	var factors []UrgencyFactor
+	add := func(name, detail string, score float64) {
+		if score != 0 {
+			factors = append(factors, UrgencyFactor{ name, detail, score })
+		}
+	}
+
+	if task.Priority != "" {
+		scale := 1 - 0.25 * float64(task.Priority[0] - 'A')
+		if scale > 0 {
+			add("priority", task.Priority, c.Priority * scale)
+		}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if !task.DueDate.IsZero() {
+		days := int(task.DueDate.Sub(today).Hours() / 24)
+
+		scale := 1.0
+		detail := "due today"
+		if days > 0 {
+			scale = 1 - 0.8 * float64(days) / 14
+			if scale < 0.2 {
+				scale = 0.2
+			}
+			detail = fmt.Sprintf("due in %d days", days)
+		} else if days < 0 {
+			detail = "overdue"
+		}
+		add("due", detail, c.Due * scale)
+
+		if days < 0 {
+			overdue := -days
+			if overdue > 30 {
+				overdue = 30
+			}
+			add("overdue", fmt.Sprintf("%d days overdue", -days), c.Overdue * float64(overdue))
+		}
+	}
+
+	if !task.CreationDate.IsZero() {
+		days := today.Sub(task.CreationDate).Hours() / 24
+		scale := days / 365
+		if scale > 1 {
+			scale = 1
+		}
+		if scale > 0 {
+			add("age", fmt.Sprintf("%.0f days old", days), c.Age * scale)
+		}
+	}
+
+	if task.Blocked {
+		add("blocked", "depends on " + strings.Join(task.Dependencies, ", "), c.Blocked)
+	}
+
+	for _, project := range task.Projects() {
+		// The most specific weight applies
+		for _, parent := range tagParents(project) {
+			if weight, ok := c.Projects[parent]; ok {
+				add("project", parent, weight)
+				break
+			}
+		}
+	}
+
+	total := 0.0
+	for _, factor := range factors {
+		total += factor.Score
+	}
+
+	return total, factors
+}
+
+// UrgencySortKey sorts the most urgent tasks first
+func (c UrgencyCoefficients) UrgencySortKey(now time.Time) SortKey {
+	// Scores are cached since every task is compared several times. Tasks which weren't parsed have no hash to cache them by.
+	scores := make(map[string]float64)
+	score := func(task Task) float64 {
+		if s, ok := scores[task.Hash]; ok && task.Hash != "" {
+			return s
+		}
+
+		s, _ := c.Urgency(task, now)
+		if task.Hash != "" {
+			scores[task.Hash] = s
+		}
+		return s
+	}
+
+	return SortKey{ "urgency", func(a, b Task) int {
+		lhs, rhs := score(a), score(b)
+		switch {
+		case lhs > rhs:
+			return -1
+		case lhs < rhs:
+			return 1
+		}
+		return 0
+	} }
+}
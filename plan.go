@@ -0,0 +1,101 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// planDay fills today with estimated (est:) open tasks ordered by due date and priority and prints the plan as a timeline
+func planDay(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	hours := fs.Float64("hours", 6, "Hours available for tasks and meetings")
+	startFlag := fs.String("start", "09:00", "Start of the day (HH:MM)")
+	ics := fs.String("ics", "", "Calendar file (.ics) with meetings")
+	stamp := fs.Bool("stamp", false, "Add scheduled: with today's date to the planned tasks")
+	fs.Parse(args)
+
+	n := time.Now()
+	clock, err := time.Parse("15:04", *startFlag)
+	if err != nil {
+		log.Fatalf("Invalid start time %s", *startFlag)
+	}
+
+	start := time.Date(n.Year(), n.Month(), n.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	end := start.Add(time.Duration(*hours * float64(time.Hour)))
+
+	var events []todo.Event
+	if *ics != "" {
+		file, err := os.Open(*ics)
+		if err != nil {
+			log.Fatalf("Unable to open calendar: %s", err)
+		}
+
+		events, err = todo.ReadICS(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Unable to read calendar %s: %s", *ics, err)
+		}
+	}
+
+	// Only tasks which can be worked on now are planned
+	candidates := todo.And(contextFilter(), parseQuery(strings.Join(fs.Args(), " ")), todo.Not(todo.Completed()), todo.Not(todo.Blocked()),
+		todo.Not(todo.KeyEquals("status", "waiting")))
+
+	order := candidates.Indexes(tasks)
+	todo.SortIndexes(tasks, order, "due,priority")
+
+	plan := todo.PlanDay(tasks, order, events, start, end)
+	planned, meetings := plan.Planned()
+
+	fmt.Printf("Plan for %s: %s of tasks, %s of meetings, %s free\n", start.Format("Mon 2006-01-02"),
+		todo.FormatDuration(planned), todo.FormatDuration(meetings), todo.FormatDuration(end.Sub(start) - planned - meetings))
+
+	for _, slot := range plan.Timeline {
+		entry := fmt.Sprintf("[meeting] %s", slot.Event.Summary)
+		if slot.Task != -1 {
+			entry = fmt.Sprintf("%03d %s", slot.Task + 1, tasks[slot.Task])
+		}
+
+		fmt.Printf("%s-%s  %s\n", slot.Start.Format("15:04"), slot.End.Format("15:04"), entry)
+	}
+
+	printPlanSection("Doesn't fit:", plan.Unplanned, tasks)
+	printPlanSection("No estimate (add est: such as est:30m):", plan.Unestimated, tasks)
+
+	if !*stamp {
+		return
+	}
+
+	backupOriginal(backup)
+
+	today := n.Format("2006-01-02")
+	for _, slot := range plan.Timeline {
+		if slot.Task != -1 {
+			tasks[slot.Task].SetValue("scheduled", today)
+		}
+	}
+
+	writeTasks(tasks)
+	log.Printf("Scheduled the planned tasks for %s", today)
+}
+
+func printPlanSection(title string, numbers []int, tasks Tasks) {
+	if len(numbers) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(title)
+	for _, i := range numbers {
+		fmt.Printf("%03d %s\n", i + 1, tasks[i])
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Standup\\, daily\r\nDTSTART:20200815T100000Z\r\nDTEND:20200815T103000Z\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Lunch with a very long\r\n  summary\r\nDTSTART;TZID=UTC:20200815T120000\r\nDTEND;TZID=UTC:20200815T130000\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20200815\r\nDTEND;VALUE=DATE:20200816\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestReadICS(t *testing.T) {
+	events, err := todo.ReadICS(strings.NewReader(testCalendar))
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v (%v)", events, err)
+	}
+
+	if events[0].Summary != "Standup, daily" || events[0].End.Sub(events[0].Start) != 30 * time.Minute {
+		t.Errorf("Unexpected first event %v", events[0])
+	}
+
+	if events[1].Summary != "Lunch with a very long summary" || events[1].Start.UTC().Hour() != 12 {
+		t.Errorf("Unexpected second event %v", events[1])
+	}
+}
+
+func TestPlanDay(t *testing.T) {
+	tasks := todo.ParseAll(`first est:1h
+too long est:3h
+no estimate
+short est:30m
+after lunch est:1h
+`)
+	events, _ := todo.ReadICS(strings.NewReader(testCalendar))
+
+	start := time.Date(2020, 8, 15, 9, 0, 0, 0, time.UTC)
+	plan := todo.PlanDay(tasks, []int{ 0, 1, 2, 3, 4 }, events, start, start.Add(5 * time.Hour))
+
+	var timeline []string
+	for _, slot := range plan.Timeline {
+		timeline = append(timeline, fmt.Sprintf("%s %d", slot.Start.UTC().Format("15:04"), slot.Task))
+	}
+
+	if expected := "[09:00 0 10:00 -1 10:30 3 11:00 4 12:00 -1]"; fmt.Sprint(timeline) != expected {
+		t.Errorf("Expected timeline %s, got %s", expected, timeline)
+	}
+
+	if fmt.Sprint(plan.Unplanned) != "[1]" || fmt.Sprint(plan.Unestimated) != "[2]" {
+		t.Errorf("Unexpected unplanned %v and unestimated %v tasks", plan.Unplanned, plan.Unestimated)
+	}
+
+	if tasks, events := plan.Planned(); tasks != 150 * time.Minute || events != 90 * time.Minute {
+		t.Errorf("Unexpected planned time %s and %s", tasks, events)
+	}
+}
+
+const recurringCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\nUID:standup\r\nSUMMARY:Standup\r\nDTSTART:20200701T090000Z\r\nDTEND:20200701T091500Z\r\n" +
+	"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR\r\nEXDATE:20200814T090000Z\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:standup\r\nSUMMARY:Late standup\r\nRECURRENCE-ID:20200812T090000Z\r\nDTSTART:20200812T110000Z\r\nDTEND:20200812T111500Z\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:sync\r\nSUMMARY:Sync\r\nDTSTART:20200803T140000Z\r\nDTEND:20200803T150000Z\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:training\r\nSUMMARY:Training\r\nDTSTART:20200810T130000Z\r\nDTEND:20200810T140000Z\r\nRRULE:FREQ=DAILY;COUNT=3\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:review\r\nSUMMARY:Review\r\nDTSTART:20200803T160000Z\r\nDTEND:20200803T170000Z\r\nRRULE:FREQ=WEEKLY;UNTIL=20200810T235959Z\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestRecurringEvents(t *testing.T) {
+	events, err := todo.ReadICS(strings.NewReader(recurringCalendar))
+	if err != nil {
+		t.Fatalf("Unable to read calendar: %s", err)
+	}
+
+	cases := map[string]string {
+		"2020-08-07": "[Standup 09:00 Sync 14:00]",
+		"2020-08-08": "[]",
+		"2020-08-10": "[Standup 09:00 Training 13:00 Review 16:00]",
+		"2020-08-12": "[Late standup 11:00 Training 13:00]",
+		"2020-08-13": "[Standup 09:00]",
+		"2020-08-14": "[]",
+		"2020-08-17": "[Standup 09:00 Sync 14:00]",
+	}
+
+	for day, expected := range cases {
+		start, _ := time.Parse("2006-01-02", day)
+
+		var found []string
+		for _, event := range events {
+			for _, occurrence := range event.Occurrences(start, start.AddDate(0, 0, 1)) {
+				found = append(found, occurrence.Summary + " " + occurrence.Start.UTC().Format("15:04"))
+			}
+		}
+
+		if fmt.Sprint(found) != expected {
+			t.Errorf(getMessage(day, "occurrences", expected, found))
+		}
+	}
+
+	// A daily meeting created weeks ago still blocks time in today's plan
+	start := time.Date(2020, 8, 13, 9, 0, 0, 0, time.UTC)
+	plan := todo.PlanDay(todo.ParseAll("task est:30m\n"), []int{ 0 }, events, start, start.Add(time.Hour))
+
+	if len(plan.Timeline) != 2 || plan.Timeline[0].Task != -1 || plan.Timeline[1].Start != start.Add(15 * time.Minute) {
+		t.Errorf("Unexpected timeline %v", plan.Timeline)
+	}
+}
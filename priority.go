@@ -0,0 +1,111 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// selectTasks returns the indexes of either the space separated task numbers or, if query isn't empty, every open task matching the query
+func selectTasks(numbers []string, query string, tasks Tasks) []int {
+	if query == "" {
+		_, selected := numbersToTasks(strings.Join(numbers, " "), tasks, "")
+		return selected
+	}
+
+	return todo.And(todo.Not(todo.Completed()), parseQuery(query)).Indexes(tasks)
+}
+
+// setPriority handles both pri (TASK... PRIORITY) and depri (TASK...)
+func setPriority(args []string, tasks Tasks, remove bool) {
+	fs := flag.NewFlagSet("pri", flag.ExitOnError)
+	query := fs.String("q", "", "Select all open tasks matching this query instead of task numbers")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	priority := ""
+
+	if !remove {
+		if len(rest) == 0 {
+			log.Fatalf("You must provide a priority")
+		}
+
+		priority = strings.ToUpper(rest[len(rest) - 1])
+		rest = rest[:len(rest) - 1]
+
+		if !todo.ValidPriority(priority) {
+			log.Fatalf("Invalid priority %s, expected a letter from A to Z", priority)
+		}
+	}
+
+	selected := selectTasks(rest, *query, tasks)
+	if len(selected) == 0 {
+		log.Fatalf("No tasks selected")
+	}
+
+	backupOriginal(backup)
+
+	var changed Tasks
+	for _, i := range selected {
+		tasks[i].SetPriority(priority)
+		changed = append(changed, tasks[i])
+	}
+
+	writeTasks(tasks)
+
+	log.Printf("Changed the priority of the following tasks:")
+	listNumberedTasks(changed, selected)
+}
+
+// reprioritize applies the escalation rules from the config to every open task
+func reprioritize(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("reprioritize", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "Only show what would change")
+	fs.Parse(args)
+
+	if len(config.Escalation) == 0 {
+		log.Fatalf("No escalation rules are configured")
+	}
+
+	n := time.Now()
+	changes := 0
+
+	for i, task := range tasks {
+		priority := todo.Escalate(task, config.Escalation, n)
+		if priority == task.Priority {
+			continue
+		}
+
+		if changes == 0 && !*dryRun {
+			backupOriginal(backup)
+		}
+		changes++
+
+		fmt.Printf("%03d (%s) -> (%s) %s\n", i + 1, displayPriority(task.Priority), priority, task.Description)
+
+		if !*dryRun {
+			tasks[i].SetPriority(priority)
+		}
+	}
+
+	if changes == 0 {
+		log.Printf("No priorities changed")
+	} else if !*dryRun {
+		writeTasks(tasks)
+	}
+}
+
+func displayPriority(priority string) string {
+	if priority == "" {
+		return "-"
+	}
+
+	return priority
+}
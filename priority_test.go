@@ -0,0 +1,55 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestEscalation(t *testing.T) {
+	rules := []todo.EscalationRule {
+		{ From: "C", To: "B", Days: 3 },
+		{ From: "B", To: "A", Days: 0 },
+	}
+	n := time.Date(2020, 8, 10, 15, 0, 0, 0, time.Local)
+
+	cases := map[string]string {
+		"(C) far away due:2020-09-01": "C",
+		"(C) soon due:2020-08-13": "B",
+		"(C) today due:2020-08-10": "A",
+		"(B) overdue due:2020-08-01": "A",
+		"x (C) completed due:2020-08-01": "C",
+		"(C) no due date": "C",
+	}
+
+	for raw, expected := range cases {
+		if actual := todo.Escalate(todo.ParseTask(raw), rules, n); actual != expected {
+			t.Errorf(getMessage(raw, "escalation", expected, actual))
+		}
+	}
+
+	// Rules forming a loop must still terminate
+	rules = append(rules, todo.EscalationRule{ From: "A", To: "C", Days: 0 })
+	if actual := todo.Escalate(todo.ParseTask("(C) looping due:2020-08-10"), rules, n); actual != "C" {
+		t.Errorf(getMessage("(C) looping due:2020-08-10", "escalation loop", "C", actual))
+	}
+
+	// Config files are checked so a typo can't write a broken priority into the list
+	valid := map[todo.EscalationRule]bool {
+		{ From: "C", To: "B" }: true,
+		{ To: "A" }: true,
+		{ From: "C", To: "b" }: false,
+		{ From: "CC", To: "B" }: false,
+		{ From: "C" }: false,
+	}
+
+	for rule, expected := range valid {
+		if actual := rule.Validate() == nil; actual != expected {
+			t.Errorf(getMessage(rule.From + " -> " + rule.To, "valid", expected, actual))
+		}
+	}
+}
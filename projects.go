@@ -0,0 +1,107 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// printTagSummary prints every project (or context) across the main file and archive as a tree with open/done counts and the next due date
+func printTagSummary(tasks Tasks, projects bool) {
+	prefix := "@"
+	if projects {
+		prefix = "+"
+	}
+
+	all := append(append(Tasks{}, tasks...), loadArchive()...)
+	summaries := todo.SummarizeTags(all, prefix)
+
+	if len(summaries) == 0 {
+		log.Printf("No tasks are tagged with %s", prefix)
+		return
+	}
+
+	for _, summary := range summaries {
+		name := summary.Tag
+		if summary.Depth > 0 {
+			// Children only show their last segment, indented below the parent
+			name = strings.Repeat("  ", summary.Depth) + name[strings.LastIndex(name, "."):]
+		}
+
+		due := ""
+		if !summary.NextDue.IsZero() {
+			due = "next due " + summary.NextDue.Format("2006-01-02")
+		}
+
+		line := fmt.Sprintf("%-30s %4d open %4d done  %s", name, summary.Open, summary.Done, due)
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+// renameTag renames a project (or context) and its children in both the main file and the archive
+func renameTag(args []string, tasks Tasks, projects bool) {
+	prefix, kind := "@", "context"
+	if projects {
+		prefix, kind = "+", "project"
+	}
+
+	if len(args) != 2 {
+		log.Fatalf("Usage: rename-%s OLD NEW", kind)
+	}
+
+	old := prefix + strings.TrimPrefix(args[0], prefix)
+	new := prefix + strings.TrimPrefix(args[1], prefix)
+	if len(old) == 1 || len(new) == 1 {
+		log.Fatalf("Names must not be empty")
+	}
+
+	archive := loadArchive()
+
+	renamed := 0
+	rename := func(tasks Tasks) bool {
+		changed := false
+		for i := range tasks {
+			if tasks[i].RenameTag(old, new) {
+				renamed++
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	changedTasks := rename(tasks)
+	changedArchive := rename(archive)
+
+	if renamed == 0 {
+		log.Printf("No tasks are tagged with %s", old)
+		return
+	}
+
+	// Both files are backed up before either is written so the rename can be undone as a whole
+	backupOriginal(backup)
+	if backup {
+		if err := store.BackupArchive(); err != nil {
+			log.Fatalf("%s", err)
+		}
+	}
+
+	var err error
+	if changedTasks && changedArchive {
+		err = store.SaveAll(tasks, archive)
+	} else if changedArchive {
+		err = store.SaveArchive(archive)
+	} else {
+		err = store.Save(tasks)
+	}
+
+	if err != nil {
+		log.Fatalf("Unable to save tasks: %s", err)
+	}
+
+	log.Printf("Renamed %s to %s in %d tasks", old, new, renamed)
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestSummarizeTags(t *testing.T) {
+	tasks := todo.ParseAll(`deploy +work.infra.k8s due:2020-08-03
+patch servers +work.infra +work.infra.k8s due:2020-08-01
+x write report +work
+buy milk +home
+`)
+
+	var actual []string
+	for _, s := range todo.SummarizeTags(tasks, "+") {
+		actual = append(actual, fmt.Sprintf("%s %d %d/%d %s", s.Tag, s.Depth, s.Open, s.Done, s.NextDue.Format("01-02")))
+	}
+
+	expected := "[+home 0 1/0 01-01 +work 0 2/1 08-01 +work.infra 1 2/0 08-01 +work.infra.k8s 2 2/0 08-01]"
+	if fmt.Sprint(actual) != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	task := todo.ParseTask("deploy +work +work.infra +workshop @office")
+
+	if !task.RenameTag("+work", "+job") {
+		t.Fatalf("Expected the task to change")
+	}
+
+	if expected := "deploy +job +job.infra +workshop @office"; task.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, task)
+	}
+
+	if task.RenameTag("+missing", "+other") {
+		t.Errorf("Renaming a missing tag should not change the task")
+	}
+
+	memory := &todo.MemoryStore {
+		Tasks: todo.ParseAll("deploy +work.infra\nbuy milk +home\n"),
+		Archived: todo.ParseAll("x old +work\nx workshop +workshop\n"),
+	}
+	saveGlobals(t)
+	store, backup, unlockStore = memory, true, nil
+
+	tasks, _ := store.Load()
+	renameTag([]string{ "work", "+job" }, tasks, true)
+
+	if formatted := listTasks(memory.Tasks); formatted != "001 deploy +job.infra\n002 buy milk +home\n" {
+		t.Errorf("Unexpected tasks after renaming:\n%s", formatted)
+	}
+
+	if formatted := listTasks(memory.Archived); formatted != "001 x old +job\n002 x workshop +workshop\n" {
+		t.Errorf("Unexpected archive after renaming:\n%s", formatted)
+	}
+}
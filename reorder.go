@@ -0,0 +1,78 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"log"
+	"strconv"
+)
+
+// taskNumber converts a 1 based task number to an index, exiting if it is out of range
+func taskNumber(raw string, tasks Tasks) int {
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 1 || number > len(tasks) {
+		log.Fatalf("Error: cannot find task %s", raw)
+	}
+
+	return number - 1
+}
+
+// moveTask moves the task at index from to index to, shifting the tasks in between
+func moveTask(tasks Tasks, from, to int) {
+	task := tasks[from]
+
+	if from < to {
+		copy(tasks[from:to], tasks[from + 1:to + 1])
+	} else {
+		copy(tasks[to + 1:from + 1], tasks[to:from])
+	}
+
+	tasks[to] = task
+}
+
+// reorderTasks implements mv, top, bottom and swap, which change the file order (the backlog rank) of tasks
+func reorderTasks(command string, args []string, tasks Tasks) {
+	usage := map[string]string {
+		"mv": "mv TASK POSITION",
+		"top": "top TASK",
+		"bottom": "bottom TASK",
+		"swap": "swap TASK TASK",
+	}
+
+	expected := 1
+	if command == "mv" || command == "swap" {
+		expected = 2
+	}
+
+	if len(args) != expected {
+		log.Fatalf("Usage: %s", usage[command])
+	}
+
+	from := taskNumber(args[0], tasks)
+	task := tasks[from]
+
+	backupOriginal(backup)
+
+	switch command {
+	case "mv":
+		to := taskNumber(args[1], tasks)
+		moveTask(tasks, from, to)
+		log.Printf("Moved task %d to %d: %s", from + 1, to + 1, task)
+
+	case "top":
+		moveTask(tasks, from, 0)
+		log.Printf("Moved task %d to the top: %s", from + 1, task)
+
+	case "bottom":
+		moveTask(tasks, from, len(tasks) - 1)
+		log.Printf("Moved task %d to the bottom: %s", from + 1, task)
+
+	case "swap":
+		other := taskNumber(args[1], tasks)
+		tasks[from], tasks[other] = tasks[other], tasks[from]
+		log.Printf("Swapped task %d and %d", from + 1, other + 1)
+	}
+
+	writeTasks(tasks)
+}
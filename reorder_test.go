@@ -0,0 +1,48 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestMoveTask(t *testing.T) {
+	cases := []struct {
+		from, to int
+		expected string
+	} {
+		{ 0, 3, "[b c d a]" },
+		{ 3, 0, "[d a b c]" },
+		{ 1, 2, "[a c b d]" },
+		{ 2, 2, "[a b c d]" },
+	}
+
+	for _, c := range cases {
+		tasks := todo.ParseAll("a\nb\nc\nd\n")
+		moveTask(tasks, c.from, c.to)
+
+		var actual []string
+		for _, task := range tasks {
+			actual = append(actual, task.Description)
+		}
+
+		if fmt.Sprint(actual) != c.expected {
+			t.Errorf("Moving %d to %d: expected %s, got %s", c.from, c.to, c.expected, actual)
+		}
+	}
+}
+
+func TestFileSortKey(t *testing.T) {
+	tasks := todo.ParseAll("(B) a\n(A) b\n(B) c\n")
+
+	indexes := []int{ 2, 0, 1 }
+	todo.SortIndexes(tasks, indexes, "priority,-file")
+
+	if fmt.Sprint(indexes) != "[1 2 0]" {
+		t.Errorf("Unexpected order %v", indexes)
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// reviewItem is a task which needs a decision and why
+type reviewItem struct {
+	index  int
+	reason string
+}
+
+// reviewCandidates returns open tasks which are overdue, have no due date and are older than staleDays or whose threshold (t:) has passed
+func reviewCandidates(tasks Tasks, n time.Time, staleDays int) []reviewItem {
+	today := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+
+	var items []reviewItem
+	for i, task := range tasks {
+		if task.Completed {
+			continue
+		}
+
+		threshold, _ := time.Parse("2006-01-02", task.Value("t"))
+
+		switch {
+		case !task.DueDate.IsZero() && task.DueDate.Before(today):
+			items = append(items, reviewItem{ i, fmt.Sprintf("overdue by %d days", int(today.Sub(task.DueDate).Hours() / 24)) })
+
+		case task.DueDate.IsZero() && !task.CreationDate.IsZero() && today.Sub(task.CreationDate) > time.Duration(staleDays) * 24 * time.Hour:
+			items = append(items, reviewItem{ i, fmt.Sprintf("no due date, created %d days ago", int(today.Sub(task.CreationDate).Hours() / 24)) })
+
+		case !threshold.IsZero() && threshold.Before(today):
+			items = append(items, reviewItem{ i, "threshold passed on " + threshold.Format("2006-01-02") })
+		}
+	}
+
+	return items
+}
+
+// reviewer reads the decisions for a review
+type reviewer struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// Called around line based prompts when single keys are read from a raw terminal
+	lineMode func()
+	keyMode  func()
+
+	edit func(string) string
+}
+
+// key returns the next key pressed, ignoring line endings and spaces. Returns 0 at the end of the input.
+func (r *reviewer) key() rune {
+	for {
+		c, _, err := r.in.ReadRune()
+		if err != nil {
+			return 0
+		}
+
+		if !unicode.IsSpace(c) {
+			return unicode.ToLower(c)
+		}
+	}
+}
+
+// line prompts for a line of input, skipping empty lines
+func (r *reviewer) line(prompt string) string {
+	if r.lineMode != nil {
+		r.lineMode()
+		defer r.keyMode()
+	}
+
+	fmt.Fprint(r.out, prompt)
+	for {
+		text, err := r.in.ReadString('\n')
+		if text = strings.TrimSpace(text); text != "" || err != nil {
+			return text
+		}
+	}
+}
+
+// review asks for a decision on every item and applies it to tasks. Returns the number of changed tasks, the indexes of
+// tasks to delete and false if the review was aborted.
+func (r *reviewer) review(tasks Tasks, items []reviewItem, n time.Time) (int, []int, bool) {
+	changed := 0
+	var deleted []int
+
+	today := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+
+	for position, item := range items {
+		task := &tasks[item.index]
+
+		fmt.Fprintf(r.out, "\n[%d/%d] %03d %s\n", position + 1, len(items), item.index + 1, task)
+		fmt.Fprintf(r.out, "%s\n", item.reason)
+
+		for done := false; !done; {
+			fmt.Fprint(r.out, "[d]o, [r]eschedule, re[p]rioritize, [e]dit, [x] delete, [s]kip, [q]uit and apply, [a]bort: ")
+			key := r.key()
+			if key != 0 {
+				fmt.Fprintf(r.out, "%c", key)
+			}
+			fmt.Fprintln(r.out)
+
+			done = true
+			switch key {
+			case 'd':
+				task.Completed = true
+				if !task.CreationDate.IsZero() {
+					task.CompletionDate = today
+				}
+				// Keep an existing status: key in sync like do does
+				if w := workflow(); task.Value("status") != "" && len(w.Closed) > 0 {
+					task.SetValue("status", w.Closed[0])
+				}
+				changed++
+
+			case 'r':
+				raw := r.line("New due date (i.e. today, tomorrow, fri or YYYY-MM-DD): ")
+				due := strings.TrimPrefix(todo.ParseDates("due:" + raw), "due:")
+				if _, err := time.Parse("2006-01-02", due); err != nil {
+					fmt.Fprintf(r.out, "Invalid date %s\n", raw)
+					done = false
+					continue
+				}
+
+				task.SetValue("due", due)
+				changed++
+
+			case 'p':
+				fmt.Fprint(r.out, "New priority (A-Z, - to remove): ")
+				priority := strings.ToUpper(string(r.key()))
+				fmt.Fprintln(r.out, priority)
+
+				if priority == "-" {
+					priority = ""
+				} else if !todo.ValidPriority(priority) {
+					fmt.Fprintf(r.out, "Invalid priority %s\n", priority)
+					done = false
+					continue
+				}
+
+				task.SetPriority(priority)
+				changed++
+
+			case 'e':
+				edited := todo.ParseTask(todo.ParseDates(r.edit(task.String())))
+				if strings.TrimSpace(edited.String()) == "" {
+					fmt.Fprintln(r.out, "The task is empty, keeping the original")
+					done = false
+					continue
+				}
+
+				*task = edited
+				changed++
+
+			case 'x':
+				deleted = append(deleted, item.index)
+				changed++
+
+			case 's':
+
+			case 'q', 0:
+				return changed, deleted, true
+
+			case 'a':
+				return 0, nil, false
+
+			default:
+				done = false
+			}
+
+			if done && key != 's' {
+				fmt.Fprintf(r.out, "-> %s\n", *task)
+			}
+		}
+	}
+
+	return changed, deleted, true
+}
+
+// rawTerminal switches the terminal to reading single keys and returns a function restoring it, or nil if stdin isn't a terminal
+func rawTerminal() (func(), func()) {
+	stat, err := os.Stdin.Stat()
+	if err != nil || stat.Mode() & os.ModeCharDevice == 0 {
+		return nil, nil
+	}
+
+	stty := func(args ...string) func() {
+		return func() {
+			cmd := exec.Command("stty", args...)
+			cmd.Stdin = os.Stdin
+			cmd.Run()
+		}
+	}
+
+	return stty("-icanon", "-echo", "min", "1"), stty("icanon", "echo")
+}
+
+// reviewCommand walks through overdue, stale and past threshold tasks and applies every decision in a single write
+func reviewCommand(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	staleDays := fs.Int("days", 30, "Review tasks without a due date created more than this many days ago")
+	fs.Parse(args)
+
+	n := time.Now()
+	items := reviewCandidates(tasks, n, *staleDays)
+	if len(items) == 0 {
+		log.Printf("Nothing to review")
+		return
+	}
+
+	// Decisions are made on a copy so nothing changes unless the review is applied
+	reviewed := append(Tasks{}, tasks...)
+
+	r := &reviewer{ in: bufio.NewReader(os.Stdin), out: os.Stdout, edit: editTask }
+	keyMode, lineMode := rawTerminal()
+	restore := func() {}
+	if keyMode != nil {
+		r.keyMode, r.lineMode = keyMode, lineMode
+
+		// Ctrl+C would otherwise leave the terminal without echo
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt)
+		go func() {
+			<-interrupted
+			lineMode()
+			fmt.Println()
+			log.Printf("Review aborted, nothing was changed")
+			os.Exit(130)
+		}()
+
+		var once sync.Once
+		restore = func() {
+			once.Do(func() {
+				signal.Stop(interrupted)
+				lineMode()
+			})
+		}
+
+		keyMode()
+		defer restore()
+	}
+
+	changed, deleted, apply := r.review(reviewed, items, n)
+	if !apply {
+		log.Printf("Review aborted, nothing was changed")
+		return
+	}
+
+	if changed == 0 {
+		log.Printf("Review finished without any changes")
+		return
+	}
+
+	fmt.Printf("\nApply %d changes? [y/N]: ", changed)
+	confirmed := r.key() == 'y'
+	fmt.Println()
+
+	// The terminal is restored before writing since a failed write exits without running deferred functions
+	restore()
+	if !confirmed {
+		log.Printf("Review aborted, nothing was changed")
+		return
+	}
+
+	backupOriginal(backup)
+
+	var removed Tasks
+	var sidecars []todo.Sidecar
+	if len(deleted) > 0 {
+		var trash todo.Sidecar
+		removed, trash = trashTasks(deleted, reviewed)
+		sidecars = append(sidecars, trash)
+	}
+
+	writeTasks(reviewed, sidecars...)
+	moveNotes(removed, "", "trash")
+
+	log.Printf("Applied %d changes", changed)
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestReview(t *testing.T) {
+	tasks := todo.ParseAll(`(B) 2020-07-01 old thing
+late due:2020-08-10
+fresh due:2020-09-01
+threshold t:2020-08-01
+2020-08-10 recent
+x 2020-08-01 2020-01-01 done due:2020-08-01
+`)
+	n := time.Date(2020, 8, 15, 12, 0, 0, 0, time.UTC)
+
+	items := reviewCandidates(tasks, n, 30)
+	var actual []string
+	for _, item := range items {
+		actual = append(actual, fmt.Sprintf("%d %s", item.index, item.reason))
+	}
+
+	expected := "[0 no due date, created 45 days ago 1 overdue by 5 days 3 threshold passed on 2020-08-01]"
+	if fmt.Sprint(actual) != expected {
+		t.Fatalf("Expected candidates %s, got %s", expected, actual)
+	}
+
+	r := &reviewer {
+		// Invalid choices and values are asked again
+		in: bufio.NewReader(strings.NewReader("d\nr\nnot a date\nr\n2020-08-20\nz\nx\n")),
+		out: ioutil.Discard,
+	}
+
+	reviewed := append(Tasks{}, tasks...)
+	changed, deleted, apply := r.review(reviewed, items, n)
+
+	if changed != 3 || fmt.Sprint(deleted) != "[3]" || !apply {
+		t.Errorf("Unexpected result: %d changed, %v deleted, %v applied", changed, deleted, apply)
+	}
+
+	if reviewed[0].String() != "x (B) 2020-08-15 2020-07-01 old thing" || reviewed[1].String() != "late due:2020-08-20" {
+		t.Errorf("Unexpected reviewed tasks %s, %s", reviewed[0], reviewed[1])
+	}
+
+	if tasks[0].Completed {
+		t.Errorf("The original tasks should not change")
+	}
+
+	r.in = bufio.NewReader(strings.NewReader("p\nA\na\n"))
+	if _, _, apply := r.review(append(Tasks{}, tasks...), items, n); apply {
+		t.Errorf("Expected the review to be aborted")
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+const highlightStart = "\x1b[1;31m"
+const highlightEnd = "\x1b[0m"
+
+// searchTasks prints every task matching the pattern with the matches highlighted.
+// Returns the exit status: 0 if anything matched, 1 if nothing did (like grep).
+func searchTasks(args []string, tasks Tasks) int {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	regex := fs.Bool("e", false, "Treat the pattern as a regular expression")
+	ignoreCase := fs.Bool("i", false, "Ignore case")
+	field := fs.String("in", "text", "Only search text, description, projects, contexts or key:NAME")
+	archive := fs.Bool("a", false, "Also search archived tasks (numbered A001, A002, ...)")
+	color := fs.String("color", "auto", "Highlight matches: auto, always or never")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatalf("Usage: search [-e] [-i] [-a] [-in FIELD] PATTERN")
+	}
+
+	pattern := strings.Join(fs.Args(), " ")
+	if !*regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Fatalf("Invalid pattern: %s", err)
+	}
+
+	highlight := *color == "always"
+	if *color == "auto" {
+		stat, err := os.Stdout.Stat()
+		highlight = err == nil && stat.Mode() & os.ModeCharDevice != 0
+	}
+
+	matched := printMatches(tasks, re, *field, "", highlight)
+
+	if *archive {
+		matched += printMatches(loadArchive(), re, *field, "A", highlight)
+	}
+
+	if matched == 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func printMatches(tasks Tasks, re *regexp.Regexp, field string, prefix string, highlight bool) int {
+	matched := 0
+
+	for i, task := range tasks {
+		line := task.String()
+
+		var matches [][]int
+		for _, segment := range searchSegments(line, task, field) {
+			for _, match := range re.FindAllStringIndex(line[segment[0]:segment[1]], -1) {
+				if match[0] != match[1] {
+					matches = append(matches, []int{ segment[0] + match[0], segment[0] + match[1] })
+				}
+			}
+		}
+
+		if len(matches) == 0 {
+			continue
+		}
+		matched++
+
+		if highlight {
+			line = highlightMatches(line, matches)
+		}
+
+		fmt.Printf("%s%03d %s\n", prefix, i + 1, line)
+	}
+
+	return matched
+}
+
+// searchSegments returns the start and end offsets of the parts of a task's line which should be searched
+func searchSegments(line string, task todo.Task, field string) [][2]int {
+	switch {
+	case field == "text":
+		return [][2]int { { 0, len(line) } }
+
+	case field == "description":
+		return [][2]int { { len(line) - len(task.Description), len(line) } }
+
+	case field == "projects" || field == "contexts":
+		prefix := "+"
+		if field == "contexts" {
+			prefix = "@"
+		}
+
+		var segments [][2]int
+		for _, token := range tokenOffsets(line) {
+			if strings.HasPrefix(line[token[0]:token[1]], prefix) {
+				segments = append(segments, token)
+			}
+		}
+		return segments
+
+	case strings.HasPrefix(field, "key:"):
+		key := strings.TrimPrefix(field, "key:") + ":"
+
+		var segments [][2]int
+		for _, token := range tokenOffsets(line) {
+			if strings.HasPrefix(line[token[0]:token[1]], key) {
+				segments = append(segments, [2]int{ token[0] + len(key), token[1] })
+			}
+		}
+		return segments
+	}
+
+	log.Fatalf("Unknown search field %s", field)
+	return nil
+}
+
+// tokenOffsets returns the start and end offset of every whitespace separated token in s
+func tokenOffsets(s string) [][2]int {
+	var tokens [][2]int
+
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start != -1 {
+				tokens = append(tokens, [2]int{ start, i })
+				start = -1
+			}
+		} else if start == -1 {
+			start = i
+		}
+	}
+
+	if start != -1 {
+		tokens = append(tokens, [2]int{ start, len(s) })
+	}
+
+	return tokens
+}
+
+// highlightMatches wraps every (sorted, non overlapping) match in terminal colors
+func highlightMatches(line string, matches [][]int) string {
+	var ret strings.Builder
+
+	last := 0
+	for _, match := range matches {
+		ret.WriteString(line[last:match[0]])
+		ret.WriteString(highlightStart)
+		ret.WriteString(line[match[0]:match[1]])
+		ret.WriteString(highlightEnd)
+		last = match[1]
+	}
+	ret.WriteString(line[last:])
+
+	return ret.String()
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestSearchSegments(t *testing.T) {
+	task := todo.ParseTask("(A) work on +work @work owner:work")
+	line := task.String()
+
+	cases := map[string]string {
+		"text": "[[0 34]]",
+		"description": "[[4 34]]",
+		"projects": "[[12 17]]",
+		"contexts": "[[18 23]]",
+		"key:owner": "[[30 34]]",
+	}
+
+	for field, expected := range cases {
+		if actual := fmt.Sprint(searchSegments(line, task, field)); actual != expected {
+			t.Errorf(getMessage(line, field, expected, actual))
+		}
+	}
+
+	expected := "a " + highlightStart + "b" + highlightEnd + " c"
+	if actual := highlightMatches("a b c", [][]int { { 2, 3 } }); actual != expected {
+		t.Errorf(getMessage("a b c", "highlight", expected, actual))
+	}
+}
+
+// captureOutput returns everything written to stdout while f runs
+func captureOutput(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Unable to create pipe: %s", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		contents, _ := ioutil.ReadAll(r)
+		output <- string(contents)
+	}()
+
+	f()
+	w.Close()
+
+	return <-output
+}
+
+func TestSearch(t *testing.T) {
+	memory := &todo.MemoryStore{ Archived: todo.ParseAll("x call bob\nx other\n") }
+	saveGlobals(t)
+	store = memory
+
+	tasks := todo.ParseAll("email bob +work\nlunch\n")
+
+	var status int
+	output := captureOutput(t, func() { status = searchTasks([]string{ "-color", "never", "bob" }, tasks) })
+	if status != 0 || output != "001 email bob +work\n" {
+		t.Errorf(getMessage("bob", "search", "001 email bob +work", output))
+	}
+
+	// Archived tasks are only included with -a and numbered separately
+	output = captureOutput(t, func() { status = searchTasks([]string{ "-a", "-color", "never", "-i", "BOB" }, tasks) })
+	if status != 0 || output != "001 email bob +work\nA001 x call bob\n" {
+		t.Errorf(getMessage("BOB", "search -a -i", "001 email bob +work\nA001 x call bob", output))
+	}
+
+	output = captureOutput(t, func() { status = searchTasks([]string{ "-color", "never", "call" }, tasks) })
+	if status != 1 || output != "" {
+		t.Errorf(getMessage("call", "search without -a", "exit status 1", fmt.Sprintf("exit status %d, %q", status, output)))
+	}
+
+	// Nothing matching is reported through the exit status like grep
+	output = captureOutput(t, func() { status = searchTasks([]string{ "-a", "-e", "^nothing$" }, tasks) })
+	if status != 1 || output != "" {
+		t.Errorf(getMessage("^nothing$", "exit status", 1, status))
+	}
+}
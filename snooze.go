@@ -0,0 +1,161 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// Offsets are whole days or weeks since due: and t: don't have a time
+var snoozeOffset = regexp.MustCompile(`^\+?(\d+)([dw])$`)
+
+// parseSnoozeTarget converts an offset (3d, 1w, +2d) into a date relative to the base date or an absolute date
+// (today, tomorrow, fri or YYYY-MM-DD as accepted by todo.ParseDates) into a fixed date
+func parseSnoozeTarget(raw string) (func(base time.Time) time.Time, error) {
+	if match := snoozeOffset.FindStringSubmatch(strings.ToLower(raw)); match != nil {
+		days, err := strconv.Atoi(match[1])
+		if err != nil || days == 0 {
+			return nil, fmt.Errorf("invalid offset %s, offsets must be at least 1d", raw)
+		}
+
+		if match[2] == "w" {
+			days *= 7
+		}
+
+		return func(base time.Time) time.Time { return base.AddDate(0, 0, days) }, nil
+	}
+
+	due := strings.TrimPrefix(todo.ParseDates("due:" + strings.ToLower(raw)), "due:")
+	date, err := time.Parse("2006-01-02", due)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date or offset %s", raw)
+	}
+
+	return func(time.Time) time.Time { return date }, nil
+}
+
+// snoozeTasks applies target to the selected tasks, printing every change and only writing when dryRun is false
+func snoozeTasks(selected []int, tasks Tasks, target func(time.Time) time.Time, dryRun bool) {
+	if len(selected) == 0 {
+		log.Fatalf("No tasks selected")
+	}
+
+	if !dryRun {
+		backupOriginal(backup)
+	}
+
+	n := time.Now()
+	changed := 0
+	for _, i := range selected {
+		task := tasks[i]
+		if !task.Snooze(target, n) {
+			continue
+		}
+		changed++
+
+		fmt.Printf("%03d due:%s t:%s -> due:%s t:%s (snoozed %sx) %s\n", i + 1,
+			displayValue(tasks[i].Value("due")), displayValue(tasks[i].Value("t")),
+			displayValue(task.Value("due")), displayValue(task.Value("t")), task.Value("snoozed"), tasks[i].Description)
+
+		tasks[i] = task
+	}
+
+	if changed == 0 {
+		log.Printf("All selected tasks are already scheduled for that date")
+		return
+	} else if dryRun {
+		log.Printf("Dry run, nothing was changed")
+		return
+	}
+
+	writeTasks(tasks)
+}
+
+func displayValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// snoozeCommand implements snooze [-n] TASK...|QUERY OFFSET
+func snoozeCommand(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("snooze", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "Only show what would change")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		log.Fatalf("Usage: snooze [-n] TASK...|QUERY OFFSET (i.e. 3d, 1w, tomorrow, fri or YYYY-MM-DD)")
+	}
+
+	target, err := parseSnoozeTarget(rest[len(rest) - 1])
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+	rest = rest[:len(rest) - 1]
+
+	// Anything other than task numbers is a query
+	query := ""
+	for _, arg := range rest {
+		if _, err := strconv.Atoi(arg); err != nil {
+			query = strings.Join(rest, " ")
+			break
+		}
+	}
+
+	snoozeTasks(selectTasks(rest, query, tasks), tasks, target, *dryRun)
+}
+
+// rescheduleCommand implements reschedule [-n] [--overdue] [QUERY] to DATE, which moves every selected open task to DATE (or an offset from today)
+func rescheduleCommand(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("reschedule", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "Only show what would change")
+	overdue := fs.Bool("overdue", false, "Only reschedule overdue tasks")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	split := -1
+	for i, arg := range rest {
+		if arg == "to" {
+			split = i
+		}
+	}
+
+	if split == -1 || split != len(rest) - 2 {
+		log.Fatalf("Usage: reschedule [-n] [--overdue] [QUERY] to DATE")
+	}
+
+	target, err := parseSnoozeTarget(rest[split + 1])
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	// Offsets are relative to today rather than the current due date, so every task ends up on the same day
+	n := time.Now()
+	today := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+	fixed := target(today)
+
+	// Rescheduling everything would also give a due date to every task without one
+	query := strings.Join(rest[:split], " ")
+	if strings.TrimSpace(query) == "" && !*overdue {
+		log.Fatalf("Select the tasks to reschedule with a QUERY or --overdue")
+	}
+
+	filter := todo.And(todo.Not(todo.Completed()), parseQuery(query))
+	if *overdue {
+		filter = todo.And(filter, todo.DueBetween(time.Time{}, today))
+	}
+
+	snoozeTasks(filter.Indexes(tasks), tasks, func(time.Time) time.Time { return fixed }, *dryRun)
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestSnooze(t *testing.T) {
+	n := time.Date(2020, 8, 15, 12, 0, 0, 0, time.UTC)
+
+	threeDays, err := parseSnoozeTarget("3d")
+	if err != nil {
+		t.Fatalf("Unable to parse offset: %s", err)
+	}
+
+	fixed, err := parseSnoozeTarget("2020-09-01")
+	if err != nil {
+		t.Fatalf("Unable to parse date: %s", err)
+	}
+
+	// Offsets shorter than a day wouldn't move a date
+	for _, raw := range []string{ "someday", "12h", "0", "0d", "+0w", "1h30m" } {
+		if _, err := parseSnoozeTarget(raw); err == nil {
+			t.Errorf("Expected an error for the invalid target %s", raw)
+		}
+	}
+
+	twoWeeks, err := parseSnoozeTarget("+2w")
+	if err != nil {
+		t.Fatalf("Unable to parse offset: %s", err)
+	}
+
+	sameDay := func(time.Time) time.Time { return time.Date(2020, 8, 20, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		task     string
+		target   func(time.Time) time.Time
+		expected string
+	} {
+		{ "future due:2020-08-20 t:2020-08-18", threeDays, "future due:2020-08-23 t:2020-08-21 snoozed:1" },
+		{ "overdue due:2020-08-01 snoozed:2", threeDays, "overdue due:2020-08-18 snoozed:3" },
+		{ "threshold only t:2020-08-16", threeDays, "threshold only t:2020-08-19 snoozed:1" },
+		{ "no dates", threeDays, "no dates due:2020-08-18 snoozed:1" },
+		{ "fixed due:2020-08-20 t:2020-08-10", fixed, "fixed due:2020-09-01 t:2020-08-22 snoozed:1" },
+		{ "weeks due:2020-08-20", twoWeeks, "weeks due:2020-09-03 snoozed:1" },
+		{ "unchanged due:2020-08-20 snoozed:1", sameDay, "unchanged due:2020-08-20 snoozed:1" },
+	}
+
+	for _, c := range cases {
+		task := todo.ParseTask(c.task)
+		task.Snooze(c.target, n)
+
+		if task.String() != c.expected {
+			t.Errorf(getMessage("snooze", c.task, c.expected, task.String()))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func printStats(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Output statistics as JSON")
+	fs.Parse(args)
+
+	all := append(append(Tasks{}, tasks...), loadArchive()...)
+	stats := todo.ComputeStats(all, time.Now())
+
+	if *asJSON {
+		raw, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			log.Fatalf("Unable to encode statistics: %s", err)
+		}
+
+		fmt.Println(string(raw))
+		return
+	}
+
+	fmt.Printf("Tasks:           %d (%d open, %d completed, %d cancelled)\n", stats.Total, stats.Open, stats.Completed, stats.Cancelled)
+	fmt.Printf("Overdue:         %d\n", stats.Overdue)
+	fmt.Printf("On time rate:    %.0f%% (%d on time, %d late)\n", stats.OnTimeRate * 100, stats.CompletedOnTime, stats.CompletedLate)
+	fmt.Printf("Avg. lead time:  %.1f days\n", stats.AverageLeadTime)
+
+	printPeriods("Per month", stats.PerMonth)
+	printPeriods("Per week", stats.PerWeek)
+	printPeriods("Per day", stats.PerDay)
+
+	printBreakdown("By project", stats.ByProject)
+	printBreakdown("By priority", stats.ByPriority)
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	switch typed := m.(type) {
+	case map[string]*todo.PeriodCount:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	case map[string]*todo.Breakdown:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func printPeriods(title string, periods map[string]*todo.PeriodCount) {
+	fmt.Printf("\n%s:\n", title)
+	fmt.Printf("  %-10s %8s %10s\n", "", "created", "completed")
+
+	for _, key := range sortedKeys(periods) {
+		fmt.Printf("  %-10s %8d %10d\n", key, periods[key].Created, periods[key].Completed)
+	}
+}
+
+func printBreakdown(title string, groups map[string]*todo.Breakdown) {
+	fmt.Printf("\n%s:\n", title)
+	fmt.Printf("  %-20s %6s %10s %8s\n", "", "open", "completed", "overdue")
+
+	for _, key := range sortedKeys(groups) {
+		fmt.Printf("  %-20s %6d %10d %8d\n", key, groups[key].Open, groups[key].Completed, groups[key].Overdue)
+	}
+}
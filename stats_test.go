@@ -0,0 +1,72 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestStats(t *testing.T) {
+	tasks := todo.ParseAll(`x 2020-08-03 2020-08-01 on time +work due:2020-08-05
+x (A) 2020-08-10 2020-08-01 late +work due:2020-08-05
+(B) 2020-08-02 overdue +home due:2020-08-06
+2020-08-12 open without due date
+`)
+
+	stats := todo.ComputeStats(tasks, time.Date(2020, 8, 15, 12, 0, 0, 0, time.UTC))
+
+	if stats.Total != 4 || stats.Open != 2 || stats.Completed != 2 || stats.Overdue != 1 {
+		t.Errorf("Unexpected totals: %d total, %d open, %d completed, %d overdue", stats.Total, stats.Open, stats.Completed, stats.Overdue)
+	}
+
+	if stats.OnTimeRate != 0.5 {
+		t.Errorf(getMessage("stats", "on time rate", 0.5, stats.OnTimeRate))
+	}
+
+	if stats.AverageLeadTime != 5.5 {
+		t.Errorf(getMessage("stats", "lead time", 5.5, stats.AverageLeadTime))
+	}
+
+	if month := stats.PerMonth["2020-08"]; month.Created != 4 || month.Completed != 2 {
+		t.Errorf(getMessage("stats", "per month", "4/2", *month))
+	}
+
+	if work := stats.ByProject["+work"]; work.Completed != 2 || work.Open != 0 {
+		t.Errorf(getMessage("stats", "+work breakdown", "0/2", *work))
+	}
+
+	if home := stats.ByProject["+home"]; home.Overdue != 1 {
+		t.Errorf(getMessage("stats", "+home overdue", 1, home.Overdue))
+	}
+}
+
+func TestCompleteTwice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saveGlobals(t)
+	filename, backup = filepath.Join(dir, "todo.txt"), false
+	openStore()
+
+	if err := ioutil.WriteFile(filename, []byte("x 2020-08-03 2020-08-01 done before\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", filename, err)
+	}
+
+	// Marking a completed task as done again must not move its completion date
+	tasks, _ := store.Load()
+	markTasks("1", tasks, true)
+
+	if contents, _ := ioutil.ReadFile(filename); string(contents) != "x 2020-08-03 2020-08-01 done before\n" {
+		t.Errorf(getMessage("done before", "completing twice", "x 2020-08-03 2020-08-01 done before", string(contents)))
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func testStore(s todo.Store, t *testing.T) {
+	if err := s.Save(todo.ParseAll("first\nx second\nthird\n")); err != nil {
+		t.Fatalf("Unable to save: %s", err)
+	}
+
+	tasks, err := s.Load()
+	if err != nil || len(tasks) != 3 {
+		t.Fatalf("Expected 3 tasks but got %d (%v)", len(tasks), err)
+	}
+
+	if err := s.Backup(); err != nil {
+		t.Errorf("Unable to backup: %s", err)
+	}
+
+	remaining, err := s.Archive(tasks)
+	if err != nil || len(remaining) != 2 {
+		t.Fatalf("Expected 2 remaining tasks but got %d (%v)", len(remaining), err)
+	}
+
+	archived, _ := s.LoadArchive()
+	if len(archived) != 1 || archived[0].String() != "x second" {
+		t.Errorf("Unexpected archive contents %v", archived)
+	}
+
+	unlock, err := s.Lock()
+	if err != nil {
+		t.Fatalf("Unable to lock: %s", err)
+	}
+
+	if _, err := s.Lock(); err == nil {
+		t.Errorf("Locking twice should fail")
+	}
+
+	unlock()
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := &todo.MemoryStore{}
+	testStore(s, t)
+
+	if len(s.Backups) != 1 || len(s.Backups[0]) != 3 {
+		t.Errorf("Expected one backup with 3 tasks")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := todo.NewFileStore(filepath.Join(dir, "todo.txt"))
+	testStore(s, t)
+
+	if _, err := os.Stat(filepath.Join(dir, "todo-done.txt")); err != nil {
+		t.Errorf("Archive was not written: %s", err)
+	}
+
+	if backup, _ := ioutil.ReadFile(filepath.Join(dir, "todo.txt.bak")); string(backup) != "first\nx second\nthird\n" {
+		t.Errorf("Unexpected backup contents %q", backup)
+	}
+
+	if _, err := todo.NewFileStore(filepath.Join(dir, "missing.txt")).Load(); err == nil {
+		t.Errorf("Loading a missing file should fail")
+	}
+
+	// Files are replaced through a temporary file which keeps the original permissions and leaves nothing behind
+	os.Chmod(filepath.Join(dir, "todo.txt"), 0600)
+	if err := s.Save(todo.ParseAll("rewritten\n")); err != nil {
+		t.Fatalf("Unable to save: %s", err)
+	}
+
+	if info, _ := os.Stat(filepath.Join(dir, "todo.txt")); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600 but got %s", info.Mode())
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp*")); len(matches) != 0 {
+		t.Errorf("Temporary files were left behind: %v", matches)
+	}
+
+	// SaveAll doesn't replace either file unless both could be written
+	s.ArchiveFilename = filepath.Join(dir, "missing", "done.txt")
+	if err := s.SaveAll(todo.ParseAll("moved back\n"), nil); err == nil {
+		t.Errorf("Expected an error writing to a missing directory")
+	}
+
+	if contents, _ := ioutil.ReadFile(filepath.Join(dir, "todo.txt")); string(contents) != "rewritten\n" {
+		t.Errorf("Unexpected contents after a failed SaveAll %q", contents)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp*")); len(matches) != 0 {
+		t.Errorf("Temporary files were left behind: %v", matches)
+	}
+}
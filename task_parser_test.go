@@ -11,6 +11,19 @@ import (
 	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
 )
 
+// saveGlobals restores the package state used by commands once the test finishes, so tests can't affect each other
+func saveGlobals(t *testing.T) {
+	oldFilename, oldDone, oldBackup, oldConfig := filename, doneFilename, backup, config
+	oldStore, oldUnlock := store, unlockStore
+	oldHooks, oldCommand, oldPreRan, oldAffected := hooksEnabled, hookCommand, hookPreRan, hookAffected
+
+	t.Cleanup(func() {
+		filename, doneFilename, backup, config = oldFilename, oldDone, oldBackup, oldConfig
+		store, unlockStore = oldStore, oldUnlock
+		hooksEnabled, hookCommand, hookPreRan, hookAffected = oldHooks, oldCommand, oldPreRan, oldAffected
+	})
+}
+
 func getMessage(task string, prop string, expect interface{}, actual interface{}) string {
 	return fmt.Sprintf("Task \"%s\" failed (%s).\nExpected: %v\nActual:   %v", task, prop, expect, actual)
 }
@@ -0,0 +1,225 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Time tracking:
+ * Every start/stop pair is recorded in FILENAME-time.txt using the id: of the task (which is assigned if the task doesn't have one).
+ * Starting the timer also moves the task to the doing status if the workflow has one.
+ * When the timer is stopped, the total time tracked for the task is also stored in its spent: key.
+ */
+
+func timeLogName() string {
+	return sidecarFilename("time")
+}
+
+func loadTimeLog() []todo.Interval {
+	raw, err := ioutil.ReadFile(timeLogName())
+	if err != nil {
+		return nil
+	}
+
+	return todo.ParseTimeLog(string(raw))
+}
+
+func writeTimeLog(intervals []todo.Interval) {
+	var contents strings.Builder
+	for _, interval := range intervals {
+		fmt.Fprintf(&contents, "%s\n", interval)
+	}
+
+	if err := ioutil.WriteFile(timeLogName(), []byte(contents.String()), 0644); err != nil {
+		log.Fatalf("Unable to write %s: %s", timeLogName(), err)
+	}
+}
+
+func startTimer(input string, tasks Tasks) {
+	_, numbers := numbersToTasks(input, tasks, "")
+	if len(numbers) != 1 {
+		log.Fatalf("You must provide exactly one task number")
+	}
+
+	n := time.Now()
+	intervals := loadTimeLog()
+
+	backupOriginal(backup)
+
+	// Only one task can be tracked at a time
+	stopTimer(intervals, tasks, n)
+
+	task := &tasks[numbers[0]]
+	if task.ID == "" {
+		task.SetValue("id", todo.NextID(tasks, loadArchive()))
+	}
+
+	// Tracking time on a task means it is being worked on
+	if workflow().Valid("doing") {
+		transitionTasks(numbers, tasks, "doing")
+	}
+
+	intervals = append(intervals, todo.Interval{ ID: task.ID, Start: n })
+
+	writeTasks(tasks)
+	writeTimeLog(intervals)
+
+	log.Printf("Started tracking %s", task)
+}
+
+// stopTimer stops any running interval and updates the spent: key of the tracked task. Returns true if a timer was running.
+func stopTimer(intervals []todo.Interval, tasks Tasks, n time.Time) bool {
+	stopped := false
+
+	for i := range intervals {
+		if !intervals[i].Running() {
+			continue
+		}
+
+		intervals[i].End = n
+		stopped = true
+
+		index := todo.FindByID(tasks, intervals[i].ID)
+		if index == -1 {
+			log.Printf("Stopped tracking task %s which is no longer in %s", intervals[i].ID, filename)
+			continue
+		}
+
+		// Sum every interval instead of adding to the existing value so rounding errors don't accumulate
+		var spent time.Duration
+		for _, interval := range intervals {
+			if interval.ID == intervals[i].ID {
+				spent += interval.Duration(n)
+			}
+		}
+		tasks[index].SetValue("spent", todo.FormatDuration(spent))
+
+		log.Printf("Stopped tracking %s after %s", tasks[index], todo.FormatDuration(intervals[i].Duration(n)))
+	}
+
+	return stopped
+}
+
+func printTimerStatus(tasks Tasks) {
+	n := time.Now()
+
+	for _, interval := range loadTimeLog() {
+		if !interval.Running() {
+			continue
+		}
+
+		description := interval.ID
+		if index := todo.FindByID(tasks, interval.ID); index != -1 {
+			description = fmt.Sprintf("%03d %s", index + 1, tasks[index])
+		}
+
+		fmt.Printf("Tracking %s for %s\n", description, todo.FormatDuration(interval.Duration(n)))
+		return
+	}
+
+	fmt.Println("No task is being tracked")
+}
+
+// parseSince converts a relative duration ("1w") or a date (YYYY-MM-DD) into the earliest time to include
+func parseSince(raw string, n time.Time) time.Time {
+	if raw == "" {
+		return time.Time{}
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return date
+	}
+
+	d, err := todo.ParseDuration(raw)
+	if err != nil {
+		log.Fatalf("Unable to parse %s as a date or duration", raw)
+	}
+
+	return n.Add(-d)
+}
+
+// timeTotals sums the time tracked since the provided time grouped by task, project or context
+func timeTotals(intervals []todo.Interval, all Tasks, since time.Time, by string, n time.Time) (map[string]time.Duration, time.Duration) {
+	totals := make(map[string]time.Duration)
+	var total time.Duration
+
+	for _, interval := range intervals {
+		if !interval.Running() && interval.End.Before(since) {
+			continue
+		}
+
+		d := interval.Clip(since).Duration(n)
+		total += d
+
+		var task todo.Task
+		if index := todo.FindByID(all, interval.ID); index != -1 {
+			task = all[index]
+		} else {
+			task.Description = "unknown task id:" + interval.ID
+		}
+
+		var groups []string
+		switch by {
+		case "task":
+			groups = []string{ task.Description }
+		case "project":
+			groups = task.Projects()
+		case "context":
+			groups = task.Contexts()
+		default:
+			log.Fatalf("Unknown grouping %s", by)
+		}
+
+		if len(groups) == 0 {
+			groups = []string{ "(none)" }
+		}
+
+		for _, group := range groups {
+			totals[group] += d
+		}
+	}
+
+	return totals, total
+}
+
+func printTimeReport(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("timereport", flag.ExitOnError)
+	sinceFlag := fs.String("since", "", "Only include time tracked since this date (YYYY-MM-DD) or duration (1w, 3d)")
+	by := fs.String("by", "task", "Group by task, project or context")
+	fs.Parse(args)
+
+	n := time.Now()
+	since := parseSince(*sinceFlag, n)
+
+	// Tracked tasks may have been archived since
+	all := append(append(Tasks{}, tasks...), loadArchive()...)
+
+	totals, total := timeTotals(loadTimeLog(), all, since, *by, n)
+
+	var keys []string
+	for key := range totals {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if totals[keys[i]] == totals[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return totals[keys[i]] > totals[keys[j]]
+	})
+
+	for _, key := range keys {
+		fmt.Printf("%8s  %s\n", todo.FormatDuration(totals[key]), key)
+	}
+	fmt.Printf("%8s  total\n", todo.FormatDuration(total))
+}
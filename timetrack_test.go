@@ -0,0 +1,132 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration {
+		"3d": 72 * time.Hour,
+		"1w": 7 * 24 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	}
+
+	for raw, expected := range cases {
+		if d, err := todo.ParseDuration(raw); err != nil || d != expected {
+			t.Errorf(getMessage(raw, "duration", expected, d))
+		}
+	}
+
+	for _, raw := range []string{ "xd", "w", "soon" } {
+		if _, err := todo.ParseDuration(raw); err == nil {
+			t.Errorf("Expected an error parsing %s", raw)
+		}
+	}
+}
+
+func TestTimeLog(t *testing.T) {
+	intervals := todo.ParseTimeLog("1 2020-08-01T09:00:00Z 2020-08-01T10:30:00Z\r\n" +
+		"garbage\n" +
+		"2 not-a-time\n" +
+		"2 2020-08-02T09:00:00Z 2020-08-02T09:45:00Z\n" +
+		"1 2020-08-03T09:00:00Z\n")
+
+	if len(intervals) != 3 || !intervals[2].Running() || intervals[0].Running() {
+		t.Fatalf("Unexpected intervals %v", intervals)
+	}
+
+	if line := intervals[0].String(); line != "1 2020-08-01T09:00:00Z 2020-08-01T10:30:00Z" {
+		t.Errorf(getMessage("interval", "string", "1 2020-08-01T09:00:00Z 2020-08-01T10:30:00Z", line))
+	}
+
+	tasks := todo.ParseAll("billing +work id:1\nreview +work +home id:2\n")
+	n := time.Date(2020, 8, 3, 10, 0, 0, 0, time.UTC)
+
+	totals, total := timeTotals(intervals, tasks, time.Time{}, "project", n)
+	if total != 3 * time.Hour + 15 * time.Minute || totals["+work"] != total || totals["+home"] != 45 * time.Minute {
+		t.Errorf("Unexpected totals %v (%s)", totals, total)
+	}
+
+	// Only the part of an interval after since is counted
+	since := time.Date(2020, 8, 1, 10, 0, 0, 0, time.UTC)
+	totals, total = timeTotals(intervals, tasks, since, "task", n)
+	if total != 2 * time.Hour + 15 * time.Minute || totals["billing +work id:1"] != 90 * time.Minute {
+		t.Errorf("Unexpected totals since %s: %v (%s)", since, totals, total)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The time log must never be written over a task file without a .txt extension
+	memory := &todo.MemoryStore{ Tasks: todo.ParseAll("first +work\nsecond id:5\n") }
+	saveGlobals(t)
+	store, filename, backup, unlockStore = memory, filepath.Join(dir, "tasks"), false, nil
+
+	if err := ioutil.WriteFile(filename, []byte("first +work\nsecond id:5\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", filename, err)
+	}
+
+	if timeLogName() != filepath.Join(dir, "tasks-time.txt") {
+		t.Errorf(getMessage("tasks", "time log name", filepath.Join(dir, "tasks-time.txt"), timeLogName()))
+	}
+
+	tasks, _ := store.Load()
+	startTimer("1", tasks)
+
+	if task := memory.Tasks[0]; task.ID != "1" || task.Value("status") != "doing" {
+		t.Errorf(getMessage("first +work", "started", "id:1 status:doing", task))
+	}
+
+	// Pretend the timer was started 90 minutes ago
+	intervals := loadTimeLog()
+	if len(intervals) != 1 || intervals[0].ID != "1" || !intervals[0].Running() {
+		t.Fatalf("Unexpected time log %v", intervals)
+	}
+	intervals[0].Start = intervals[0].Start.Add(-90 * time.Minute)
+	writeTimeLog(intervals)
+
+	// Starting another task stops the running one
+	tasks, _ = store.Load()
+	startTimer("2", tasks)
+
+	intervals = loadTimeLog()
+	if len(intervals) != 2 || intervals[0].Running() || !intervals[1].Running() || intervals[1].ID != "5" {
+		t.Fatalf("Unexpected time log %v", intervals)
+	}
+
+	if spent := memory.Tasks[0].Value("spent"); spent != "1h30m" {
+		t.Errorf(getMessage("first +work", "spent", "1h30m", spent))
+	}
+
+	tasks, _ = store.Load()
+	if !stopTimer(intervals, tasks, intervals[1].Start.Add(20 * time.Minute)) {
+		t.Errorf("Expected a running timer to be stopped")
+	}
+
+	if spent := tasks[1].Value("spent"); spent != "20m" {
+		t.Errorf(getMessage("second id:5", "spent", "20m", spent))
+	}
+
+	if stopTimer(intervals, tasks, time.Now()) {
+		t.Errorf("Expected no timer to be running")
+	}
+
+	if contents, _ := ioutil.ReadFile(filename); string(contents) != "first +work\nsecond id:5\n" {
+		t.Errorf("%s was modified: %s", filename, fmt.Sprintf("%q", contents))
+	}
+}
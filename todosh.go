@@ -0,0 +1,293 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Compatibility with todo.sh (https://github.com/todotxt/todo.txt-cli):
+ * The TODO_DIR, TODO_FILE and DONE_FILE environment variables select the files to use unless -f is given
+ * and add-on actions are run from ~/.todo.actions.d (or TODO_ACTIONS_DIR).
+ */
+
+// isFlagSet returns true if the named flag was explicitly provided on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// todoShFiles returns the task and done files selected by the todo.sh environment variables.
+// If none are set, the fallback task file is used with the default archive name.
+func todoShFiles(fallback string) (string, string) {
+	dir := os.Getenv("TODO_DIR")
+	todoFile := os.Getenv("TODO_FILE")
+	doneFile := os.Getenv("DONE_FILE")
+
+	if todoFile == "" && dir != "" {
+		todoFile = filepath.Join(dir, "todo.txt")
+	}
+	if doneFile == "" && dir != "" {
+		doneFile = filepath.Join(dir, "done.txt")
+	}
+
+	if todoFile == "" {
+		todoFile = fallback
+	}
+
+	return todoFile, doneFile
+}
+
+func actionsDir() string {
+	if dir := os.Getenv("TODO_ACTIONS_DIR"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".todo.actions.d")
+}
+
+// findAction returns the path of the executable add-on action for the command or "" if there isn't one.
+// Like todo.sh, actions can either be ACTIONS_DIR/action or ACTIONS_DIR/action/action.
+func findAction(command string) string {
+	dir := actionsDir()
+	if command == "" || dir == "" || strings.ContainsRune(command, os.PathSeparator) {
+		return ""
+	}
+
+	for _, path := range []string { filepath.Join(dir, command), filepath.Join(dir, command, command) } {
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() && info.Mode() & 0111 != 0 {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// runAction executes an add-on action the same way todo.sh does (the action name followed by all arguments) and returns its exit status
+func runAction(path string, args []string) int {
+	dir := filepath.Dir(filename)
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"TODO_DIR=" + dir,
+		"TODO_FILE=" + filename,
+		"DONE_FILE=" + archiveFilename(),
+		"REPORT_FILE=" + reportFilename(),
+		"TODO_SH=" + os.Args[0],
+		"TODO_FULL_SH=" + os.Args[0])
+
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			return exit.ExitCode()
+		}
+
+		log.Fatalf("Unable to run action %s: %s", path, err)
+	}
+
+	return 0
+}
+
+// splitNumber splits "ITEM TEXT" into the task index and text
+func splitNumber(input string, tasks Tasks) (int, string) {
+	fields := strings.SplitN(strings.TrimSpace(input), " ", 2)
+	if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+		log.Fatalf("You must provide a task number and text")
+	}
+
+	_, numbers := numbersToTasks(fields[0], tasks, "")
+	return numbers[0], strings.TrimSpace(fields[1])
+}
+
+// appendTask adds text to the end (or beginning) of the task's description
+func appendTask(input string, tasks Tasks, prepend bool) {
+	i, text := splitNumber(input, tasks)
+	text = todo.ParseDates(text)
+
+	backupOriginal(backup)
+
+	if prepend {
+		tasks[i].Description = text + " " + tasks[i].Description
+	} else {
+		tasks[i].Description = tasks[i].Description + " " + text
+	}
+	tasks[i] = todo.ParseTask(tasks[i].String())
+
+	writeTasks(tasks)
+
+	fmt.Printf("%03d %s\n", i + 1, tasks[i])
+}
+
+// replaceTask replaces the whole task, keeping the priority and creation date unless the new text sets them
+func replaceTask(input string, tasks Tasks) {
+	i, text := splitNumber(input, tasks)
+	old := tasks[i]
+
+	replacement := todo.ParseTask(todo.ParseDates(text))
+	if replacement.Priority == "" {
+		replacement.Priority = old.Priority
+	}
+	if replacement.CreationDate.IsZero() {
+		replacement.CreationDate = old.CreationDate
+	}
+	replacement = todo.ParseTask(replacement.String())
+
+	backupOriginal(backup)
+
+	tasks[i] = replacement
+	writeTasks(tasks)
+
+	fmt.Printf("%03d %s\n", i + 1, old)
+	fmt.Printf("TODO: Replaced task with:\n")
+	fmt.Printf("%03d %s\n", i + 1, tasks[i])
+}
+
+// listPriorities lists open tasks with a priority, optionally limited to a set ("AB") or range ("A-C") of priorities
+func listPriorities(input string, tasks Tasks) {
+	allowed := strings.ToUpper(strings.TrimSpace(input))
+	if len(allowed) == 3 && allowed[1] == '-' {
+		expanded := ""
+		for c := allowed[0]; c <= allowed[2]; c++ {
+			expanded += string(c)
+		}
+		allowed = expanded
+	}
+
+	var numbers []int
+	for i, task := range tasks {
+		if task.Priority == "" || task.Completed {
+			continue
+		} else if allowed != "" && !strings.Contains(allowed, task.Priority) {
+			continue
+		}
+
+		numbers = append(numbers, i)
+	}
+
+	sort.SliceStable(numbers, func(i, j int) bool {
+		return tasks[numbers[i]].Priority < tasks[numbers[j]].Priority
+	})
+
+	for _, i := range numbers {
+		fmt.Printf("%03d %s\n", i + 1, tasks[i])
+	}
+}
+
+// listTags prints every distinct project (or context) used by a task
+func listTags(tasks Tasks, projects bool) {
+	seen := make(map[string]bool)
+	var tags []string
+
+	for _, task := range tasks {
+		found := task.Contexts()
+		if projects {
+			found = task.Projects()
+		}
+
+		for _, tag := range found {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Println(tag)
+	}
+}
+
+// listAll lists tasks in the main file followed by the archive (numbered 0 like todo.sh), optionally filtered by a term
+func listAll(term string, tasks Tasks) {
+	term = strings.ToLower(strings.TrimSpace(term))
+
+	for i, task := range tasks {
+		if strings.Contains(strings.ToLower(task.String()), term) {
+			fmt.Printf("%03d %s\n", i + 1, task)
+		}
+	}
+
+	for _, task := range loadArchive() {
+		if strings.Contains(strings.ToLower(task.String()), term) {
+			fmt.Printf("%03d %s\n", 0, task)
+		}
+	}
+}
+
+// deduplicate removes every task which is identical to an earlier one
+func deduplicate(tasks Tasks) {
+	seen := make(map[string]bool)
+	removed := 0
+
+	for i, task := range tasks {
+		line := task.String()
+		if seen[line] {
+			tasks[i].Deleted = true
+			removed++
+			continue
+		}
+
+		seen[line] = true
+	}
+
+	if removed == 0 {
+		fmt.Println("TODO: No duplicate tasks found")
+		return
+	}
+
+	backupOriginal(backup)
+	writeTasks(tasks)
+
+	fmt.Printf("TODO: %d duplicate task(s) removed\n", removed)
+}
+
+func reportFilename() string {
+	if report := os.Getenv("REPORT_FILE"); report != "" {
+		return report
+	}
+
+	return filepath.Join(filepath.Dir(filename), "report.txt")
+}
+
+// todoShReport archives completed tasks and appends a line with the number of open and done tasks to the report file
+func todoShReport(tasks Tasks) {
+	remaining := archiveTasks(tasks)
+	done := len(loadArchive())
+
+	line := fmt.Sprintf("%s %d %d\n", time.Now().Format("2006-01-02T15:04:05"), len(remaining), done)
+
+	report := reportFilename()
+	existing, _ := ioutil.ReadFile(report)
+	if err := ioutil.WriteFile(report, append(existing, []byte(line)...), 0644); err != nil {
+		log.Fatalf("Unable to write %s: %s", report, err)
+	}
+
+	fmt.Print(line)
+	fmt.Printf("TODO: Report file updated.\n")
+}
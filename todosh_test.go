@@ -0,0 +1,67 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestActions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Add-on actions need a POSIX shell")
+	}
+
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldDir := os.Getenv("TODO_ACTIONS_DIR")
+	os.Setenv("TODO_ACTIONS_DIR", dir)
+	defer os.Setenv("TODO_ACTIONS_DIR", oldDir)
+
+	saveGlobals(t)
+	filename = filepath.Join(dir, "todo.txt")
+	output := filepath.Join(dir, "output.txt")
+
+	writeHook(t, dir, "hello", "echo \"$@ $TODO_FILE $DONE_FILE\" > '" + output + "'\nexit 3\n")
+	writeHook(t, dir, "notexec", "exit 0\n")
+	os.Chmod(filepath.Join(dir, "notexec"), 0644)
+
+	// Actions can also live in a directory of the same name
+	os.Mkdir(filepath.Join(dir, "nested"), 0755)
+	writeHook(t, filepath.Join(dir, "nested"), "nested", "exit 0\n")
+
+	cases := map[string]string {
+		"hello": filepath.Join(dir, "hello"),
+		"nested": filepath.Join(dir, "nested", "nested"),
+		"notexec": "",
+		"missing": "",
+		"nested/nested": "",
+		"": "",
+	}
+
+	for command, expected := range cases {
+		if actual := findAction(command); actual != expected {
+			t.Errorf(getMessage(command, "action", expected, actual))
+		}
+	}
+
+	// Actions get the action name followed by all arguments and their exit status is passed on
+	if status := runAction(findAction("hello"), []string{ "hello", "a", "b" }); status != 3 {
+		t.Errorf(getMessage("hello", "exit status", 3, status))
+	}
+
+	contents, _ := ioutil.ReadFile(output)
+	expected := "hello a b " + filename + " " + filepath.Join(dir, "todo-done.txt")
+	if strings.TrimSpace(string(contents)) != expected {
+		t.Errorf(getMessage("hello", "arguments and environment", expected, string(contents)))
+	}
+}
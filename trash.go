@@ -0,0 +1,186 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Trash:
+ * Removed tasks are moved to FILENAME-trash.txt with two extra keys:
+ *	deleted:TIMESTAMP	when the task was removed (RFC3339 in UTC)
+ *	pos:N				the task's number in the main file, used by restore
+ */
+
+func loadTrash() Tasks {
+	trash, err := store.LoadSidecar("trash")
+	if err != nil {
+		log.Fatalf("Unable to load trash: %s", err)
+	}
+
+	return trash
+}
+
+func trashSidecar(trash Tasks) todo.Sidecar {
+	return todo.Sidecar{ Name: "trash", Tasks: trash }
+}
+
+// trashedAt returns when a trashed task was removed or the zero time if it isn't known
+func trashedAt(task todo.Task) time.Time {
+	deleted, _ := time.Parse(time.RFC3339, task.Value("deleted"))
+	return deleted
+}
+
+// removeTasks moves tasks to the trash or, with --permanent, deletes them
+func removeTasks(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("rm", flag.ExitOnError)
+	permanent := fs.Bool("permanent", false, "Delete the tasks instead of moving them to the trash")
+	fs.Parse(args)
+
+	msg := "Moved the following tasks to the trash:"
+	if *permanent {
+		msg = "Removed the following tasks:"
+	}
+
+	_, numbers := numbersToTasks(strings.Join(fs.Args(), " "), tasks, msg)
+
+	if *permanent {
+		var removed Tasks
+		for _, i := range numbers {
+			tasks[i].Deleted = true
+			removed = append(removed, tasks[i])
+		}
+
+		writeTasks(tasks)
+		deleteNotes(removed, "")
+		return
+	}
+
+	removed, trash := trashTasks(numbers, tasks)
+	writeTasks(tasks, trash)
+	moveNotes(removed, "", "trash")
+}
+
+// trashTasks marks tasks as deleted and adds them to the trash. Returns the removed tasks and the trash, which must be
+// saved along with the tasks.
+func trashTasks(numbers []int, tasks Tasks) (Tasks, todo.Sidecar) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	trash := loadTrash()
+
+	var removed Tasks
+	for _, i := range numbers {
+		removed = append(removed, tasks[i])
+
+		task := tasks[i]
+		task.SetValue("deleted", now)
+		task.SetValue("pos", strconv.Itoa(i + 1))
+		trash = append(trash, task)
+
+		tasks[i].Deleted = true
+	}
+
+	return removed, trashSidecar(trash)
+}
+
+// trashCommand lists the trash or, with empty, purges it
+func trashCommand(args []string, tasks Tasks) {
+	trash := loadTrash()
+
+	if len(args) == 0 {
+		for i, task := range trash {
+			fmt.Printf("%03d %s\n", i + 1, task)
+		}
+		return
+	}
+
+	if args[0] != "empty" {
+		log.Fatalf("Usage: trash [empty [--older-than 30d]]")
+	}
+
+	fs := flag.NewFlagSet("trash empty", flag.ExitOnError)
+	olderThan := fs.String("older-than", "", "Only purge tasks removed longer ago than this (i.e. 30d)")
+	fs.Parse(args[1:])
+
+	cutoff := time.Now()
+	if *olderThan != "" {
+		age, err := todo.ParseDuration(*olderThan)
+		if err != nil {
+			log.Fatalf("Invalid age %s: %s", *olderThan, err)
+		}
+		cutoff = cutoff.Add(-age)
+	}
+
+	var purged Tasks
+	for i, task := range trash {
+		// Tasks without a deletion time were added by hand and are only purged when emptying everything
+		deleted := trashedAt(task)
+		if *olderThan != "" && (deleted.IsZero() || deleted.After(cutoff)) {
+			continue
+		}
+
+		trash[i].Deleted = true
+		purged = append(purged, task)
+	}
+
+	if len(purged) == 0 {
+		log.Printf("Nothing to purge")
+		return
+	}
+
+	writeTasks(tasks, trashSidecar(trash))
+
+	deleteNotes(purged, "trash")
+
+	log.Printf("Purged %d tasks from the trash", len(purged))
+}
+
+// restoreTasks moves tasks from the trash back to their original position in the main file (or the end if it no longer exists)
+func restoreTasks(input string, tasks Tasks) {
+	trash := loadTrash()
+
+	selected, numbers := numbersToTasks(input, trash, "")
+	if len(selected) == 0 {
+		log.Fatalf("You must provide at least one trashed task number")
+	}
+
+	backupOriginal(backup)
+
+	// Restoring from the lowest position up keeps earlier positions valid
+	sort.SliceStable(selected, func(i, j int) bool {
+		lhs, _ := strconv.Atoi(selected[i].Value("pos"))
+		rhs, _ := strconv.Atoi(selected[j].Value("pos"))
+		return lhs < rhs
+	})
+
+	for _, task := range selected {
+		position, err := strconv.Atoi(task.Value("pos"))
+		if err != nil || position < 1 || position > len(tasks) + 1 {
+			position = len(tasks) + 1
+		}
+
+		task.RemoveValue("deleted")
+		task.RemoveValue("pos")
+
+		tasks = append(tasks, todo.Task{})
+		copy(tasks[position:], tasks[position - 1:])
+		tasks[position - 1] = task
+
+		log.Printf("Restored task %d: %s", position, task)
+	}
+
+	for _, i := range numbers {
+		trash[i].Deleted = true
+	}
+
+	writeTasks(tasks, trashSidecar(trash))
+	moveNotes(selected, "trash", "")
+}
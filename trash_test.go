@@ -0,0 +1,106 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestTrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saveGlobals(t)
+	filename, backup, unlockStore = filepath.Join(dir, "tasks"), false, nil
+	openStore()
+
+	if err := ioutil.WriteFile(filename, []byte("a\nb\nc\nd\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", filename, err)
+	}
+
+	// The trash is part of the same write as the task file so hooks see both
+	var written []string
+	store.(*todo.FileStore).BeforeWrite = func(writes []todo.FileWrite) error {
+		for _, w := range writes {
+			written = append(written, filepath.Base(w.Filename))
+		}
+		return nil
+	}
+
+	tasks, _ := store.Load()
+	removeTasks([]string{ "2", "4" }, tasks)
+
+	if strings.Join(written, " ") != "tasks-trash.txt tasks" {
+		t.Errorf(getMessage("rm 2 4", "files written", "tasks-trash.txt tasks", strings.Join(written, " ")))
+	}
+	unlockStore()
+	unlockStore = nil
+
+	if tasks, _ := store.Load(); listTasks(tasks) != "001 a\n002 c\n" {
+		t.Errorf("Unexpected tasks after rm:\n%s", listTasks(tasks))
+	}
+
+	// The trash is kept next to the task file even if it doesn't end in .txt
+	trash := loadTasks(filepath.Join(dir, "tasks-trash.txt"), false)
+	if len(trash) != 2 || trash[0].Value("pos") != "2" || trashedAt(trash[1]).IsZero() {
+		t.Fatalf("Unexpected trash %v", trash)
+	}
+
+	tasks, _ = store.Load()
+	restoreTasks("2 1", tasks)
+	unlockStore()
+
+	if tasks, _ := store.Load(); listTasks(tasks) != "001 a\n002 b\n003 c\n004 d\n" {
+		t.Errorf("Unexpected tasks after restore:\n%s", listTasks(tasks))
+	}
+
+	if trash := loadTrash(); len(trash) != 0 {
+		t.Errorf("Expected an empty trash, got %v", trash)
+	}
+}
+
+func TestRemovePermanent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	memory := &todo.MemoryStore{ Tasks: todo.ParseAll("first note:first.md\nsecond\n") }
+	saveGlobals(t)
+	store, filename, backup, unlockStore = memory, filepath.Join(dir, "todo.txt"), false, nil
+
+	note := filepath.Join(notesDir(), "first.md")
+	os.MkdirAll(notesDir(), 0755)
+	if err := ioutil.WriteFile(note, []byte("# first\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", note, err)
+	}
+
+	tasks, _ := store.Load()
+	removeTasks([]string{ "--permanent", "1" }, tasks)
+
+	if formatted := listTasks(memory.Tasks); formatted != "001 second\n" {
+		t.Errorf("Unexpected tasks after rm --permanent:\n%s", formatted)
+	}
+
+	// Permanently removed tasks don't leave their notes behind in the trash
+	for _, path := range []string{ note, filepath.Join(notesDir(), "trash", "first.md") } {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted (%v)", path, err)
+		}
+	}
+
+	if len(memory.Sidecars["trash"]) != 0 {
+		t.Errorf("Expected an empty trash, got %v", memory.Sidecars["trash"])
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// listArchive prints archived tasks matched by the filter, numbered by their position in the archive (A001, A002, ...)
+func listArchive(filter todo.Filter) {
+	for i, task := range loadArchive() {
+		if filter(task) {
+			fmt.Printf("A%03d %s\n", i + 1, task)
+		}
+	}
+}
+
+// selectArchived returns the indexes of archived tasks selected by an archive number (A3), an id: value or a query.
+// Bare numbers are only ever ids so they can't silently pick the wrong task.
+func selectArchived(selector string, archive Tasks) []int {
+	if strings.HasPrefix(selector, "A") {
+		if number, err := strconv.Atoi(selector[1:]); err == nil {
+			if number < 1 || number > len(archive) {
+				log.Fatalf("Error: cannot find archived task %s", selector)
+			}
+			return []int{ number - 1 }
+		}
+	}
+
+	if i := todo.FindByID(archive, selector); i != -1 {
+		return []int{ i }
+	}
+
+	if _, err := strconv.Atoi(selector); err == nil {
+		log.Fatalf("Error: no archived task has id:%s, use A%s to select by archive number", selector, selector)
+	}
+
+	return parseQuery(selector).Indexes(archive)
+}
+
+// unarchiveTasks moves archived tasks back to the main list, optionally marking them incomplete
+func unarchiveTasks(args []string, tasks Tasks) {
+	fs := flag.NewFlagSet("unarchive", flag.ExitOnError)
+	reopen := fs.Bool("reopen", false, "Mark the tasks as incomplete")
+	fs.Parse(args)
+
+	selector := strings.Join(fs.Args(), " ")
+	if selector == "" {
+		log.Fatalf("Usage: unarchive [--reopen] QUERY|ID|ANUMBER (archive numbers are shown by list --archive, i.e. A3)")
+	}
+
+	archive := loadArchive()
+	selected := selectArchived(selector, archive)
+	if len(selected) == 0 {
+		log.Fatalf("No archived tasks match %s", selector)
+	}
+
+	// Both files are backed up before either is written so the whole operation can be undone
+	backupOriginal(backup)
+	if backup {
+		if err := store.BackupArchive(); err != nil {
+			log.Fatalf("%s", err)
+		}
+	}
+
+	log.Printf("Unarchived the following tasks:")
+	for _, i := range selected {
+		task := archive[i]
+		archive[i].Deleted = true
+
+		if *reopen {
+			task.Completed = false
+			task.CompletionDate = todo.EmptyDate
+		}
+
+		tasks = append(tasks, task)
+		fmt.Printf("%03d %s\n", len(tasks), task)
+	}
+
+	// Both files are replaced together so a failure can't leave the tasks in both of them
+	if err := store.SaveAll(tasks, archive); err != nil {
+		log.Fatalf("Unable to save tasks: %s", err)
+	}
+
+	moveNotes(tasks[len(tasks) - len(selected):], "archive", "")
+}
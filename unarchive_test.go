@@ -0,0 +1,48 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestUnarchive(t *testing.T) {
+	memory := &todo.MemoryStore {
+		Tasks: todo.ParseAll("open\n"),
+		Archived: todo.ParseAll("x 2020-01-02 2020-01-01 first +work id:7\nx second +home\nx third +work\n"),
+	}
+	saveGlobals(t)
+	store, backup, unlockStore = memory, true, nil
+
+	cases := map[string]string {
+		"7": "[0]",
+		"A2": "[1]",
+		"A3": "[2]",
+		"+work": "[0 2]",
+	}
+
+	for selector, expected := range cases {
+		if actual := fmt.Sprint(selectArchived(selector, memory.Archived)); actual != expected {
+			t.Errorf("Selecting %s: expected %s, got %s", selector, expected, actual)
+		}
+	}
+
+	tasks, _ := store.Load()
+	unarchiveTasks([]string{ "--reopen", "+work" }, tasks)
+
+	if formatted := listTasks(memory.Tasks); formatted != "001 open\n002 2020-01-01 first +work id:7\n003 third +work\n" {
+		t.Errorf("Unexpected tasks after unarchive:\n%s", formatted)
+	}
+
+	if len(memory.Archived) != 1 || memory.Archived[0].String() != "x second +home" {
+		t.Errorf("Unexpected archive %v", memory.Archived)
+	}
+
+	if len(memory.Backups) != 1 || len(memory.ArchiveBackups) != 1 {
+		t.Errorf("Expected both files to be backed up")
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// customSortKeys are the sort keys which depend on the config, available to list and views
+func customSortKeys() []todo.SortKey {
+	return []todo.SortKey{ config.Urgency.UrgencySortKey(time.Now()) }
+}
+
+// printNext prints the most urgent open tasks along with the factors of their urgency
+func printNext(args []string, tasks Tasks) {
+	count := 5
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 {
+			log.Fatalf("Usage: next [N]")
+		}
+		count = parsed
+	}
+
+	numbers := todo.And(contextFilter(), todo.Not(todo.Completed())).Indexes(tasks)
+	todo.SortIndexes(tasks, numbers, "urgency", customSortKeys()...)
+
+	if len(numbers) > count {
+		numbers = numbers[:count]
+	}
+
+	n := time.Now()
+	for rank, i := range numbers {
+		score, factors := config.Urgency.Urgency(tasks[i], n)
+
+		var reasons []string
+		for _, factor := range factors {
+			reasons = append(reasons, fmt.Sprintf("%s %+.1f (%s)", factor.Name, factor.Score, factor.Detail))
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, "nothing makes this task urgent")
+		}
+
+		fmt.Printf("%d. %03d %s\n", rank + 1, i + 1, tasks[i])
+		fmt.Printf("   urgency %.1f: %s\n", score, strings.Join(reasons, ", "))
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestUrgency(t *testing.T) {
+	tasks := todo.ParseAll(`(A) fix prod +work.infra due:2020-08-15
+(C) 2020-02-16 buy milk +home
+write blog due:2020-08-05
+id:9 base
+(A) blocked dep:9
+x (A) done due:2020-08-01
+`)
+	todo.UpdateBlocked(tasks)
+
+	coefficients := todo.DefaultUrgency
+	coefficients.Projects = map[string]float64{ "+work": 3, "+work.infra": 1 }
+	n := time.Date(2020, 8, 15, 12, 0, 0, 0, time.UTC)
+
+	expected := []float64{ 19, 4, 17, 0, 1, 0 }
+	for i, task := range tasks {
+		score, factors := coefficients.Urgency(task, n)
+		if fmt.Sprintf("%.1f", score) != fmt.Sprintf("%.1f", expected[i]) {
+			t.Errorf("Expected urgency %.1f for %s, got %.1f (%v)", expected[i], task, score, factors)
+		}
+	}
+
+	indexes := []int{ 0, 1, 2, 3, 4, 5 }
+	todo.SortIndexes(tasks, indexes, "urgency", coefficients.UrgencySortKey(n))
+	if fmt.Sprint(indexes) != "[0 2 1 4 3 5]" {
+		t.Errorf("Unexpected urgency order %v", indexes)
+	}
+}
+
+func TestUrgencySortUnparsed(t *testing.T) {
+	// Tasks built without ParseTask have no hash but still get their own score
+	tasks := Tasks{ { Description: "low", Priority: "C" }, { Description: "high", Priority: "A" } }
+	indexes := []int{ 0, 1 }
+
+	if err := todo.SortIndexes(tasks, indexes, "urgency", todo.DefaultUrgency.UrgencySortKey(time.Now())); err != nil {
+		t.Fatalf("Unable to sort: %s", err)
+	}
+
+	if fmt.Sprint(indexes) != "[1 0]" {
+		t.Errorf(getMessage("low, high", "urgency order", "[1 0]", indexes))
+	}
+}
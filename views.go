@@ -0,0 +1,123 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+// View is a named combination of a filter, sort order and output format which can be run as a subcommand
+type View struct {
+	Filter string `json:"filter"`		// Query as accepted by list
+	Sort   string `json:"sort"`		// Comma separated sort keys (see todo.SortIndexes and urgency), defaults to file order
+	Format string `json:"format"`		// text/template executed for every task with .Number and .Task, defaults to "NNN task"
+}
+
+const defaultViewFormat = `{{printf "%03d" .Number}} {{.Task}}`
+
+// contextFilename holds the query of the active context
+func contextFilename() string {
+	return sidecarFilename("context")
+}
+
+// activeContext returns the query of the active context or "" if none is set
+func activeContext() string {
+	raw, err := ioutil.ReadFile(contextFilename())
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(raw))
+}
+
+// contextFilter returns the filter of the active context (matching everything if none is set) and prints a banner if one is active
+func contextFilter() todo.Filter {
+	query := activeContext()
+	if query == "" {
+		return todo.All()
+	}
+
+	log.Printf("Context: %s", query)
+	return parseQuery(query)
+}
+
+// contextCommand shows, sets or clears the active context
+func contextCommand(args []string) {
+	if len(args) == 0 {
+		if query := activeContext(); query != "" {
+			fmt.Println(query)
+		} else {
+			log.Printf("No context is active")
+		}
+		return
+	}
+
+	switch args[0] {
+	case "set":
+		query := strings.Join(args[1:], " ")
+		if strings.TrimSpace(query) == "" {
+			log.Fatalf("Usage: context set QUERY")
+		}
+
+		// Refuse to save a query which would break every later command
+		parseQuery(query)
+
+		if err := ioutil.WriteFile(contextFilename(), []byte(query + "\n"), 0644); err != nil {
+			log.Fatalf("Unable to save context: %s", err)
+		}
+		log.Printf("Context set to %s", query)
+
+	case "clear":
+		if err := os.Remove(contextFilename()); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("Unable to clear context: %s", err)
+		}
+		log.Printf("Context cleared")
+
+	default:
+		log.Fatalf("Usage: context [set QUERY | clear]")
+	}
+}
+
+// runView prints the tasks selected by a view from the config
+func runView(name string, tasks Tasks) {
+	view, ok := config.Views[name]
+	if !ok {
+		log.Fatalf("No view named %s, views are defined in the config", name)
+	}
+
+	format := view.Format
+	if format == "" {
+		format = defaultViewFormat
+	}
+
+	tmpl, err := template.New(name).Parse(format + "\n")
+	if err != nil {
+		log.Fatalf("Invalid format for view %s: %s", name, err)
+	}
+
+	numbers := parseQuery(view.Filter).Indexes(tasks)
+	if err := todo.SortIndexes(tasks, numbers, view.Sort, customSortKeys()...); err != nil {
+		log.Fatalf("Invalid sort for view %s: %s", name, err)
+	}
+
+	log.Printf("View: %s", name)
+
+	for _, i := range numbers {
+		data := struct {
+			Number int
+			Task   todo.Task
+		}{ i + 1, tasks[i] }
+
+		if err := tmpl.Execute(os.Stdout, data); err != nil {
+			log.Fatalf("Unable to format task %d: %s", i + 1, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestSortIndexes(t *testing.T) {
+	tasks := todo.ParseAll(`(B) second due:2020-08-02
+no priority due:2020-08-01
+(A) first
+(B) third due:2020-08-01
+`)
+
+	cases := map[string]string {
+		"": "[0 1 2 3]",
+		"due": "[1 3 0 2]",
+		"priority": "[2 0 3 1]",
+		"priority,due": "[2 3 0 1]",
+		"-due": "[2 0 1 3]",
+		"description": "[2 1 0 3]",
+	}
+
+	for keys, expected := range cases {
+		indexes := []int{ 0, 1, 2, 3 }
+		if err := todo.SortIndexes(tasks, indexes, keys); err != nil {
+			t.Errorf("Unable to sort by %q: %s", keys, err)
+		} else if fmt.Sprint(indexes) != expected {
+			t.Errorf("Sorting by %q: expected %s, got %v", keys, expected, indexes)
+		}
+	}
+
+	if err := todo.SortIndexes(tasks, []int{ 0 }, "color"); err == nil {
+		t.Errorf("Expected an error for an unknown sort key")
+	}
+}
+
+func TestContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The context is kept next to the task file even if it doesn't end in .txt
+	saveGlobals(t)
+	filename = filepath.Join(dir, "tasks")
+	if err := ioutil.WriteFile(filename, []byte("call bob @work\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", filename, err)
+	}
+
+	if activeContext() != "" {
+		t.Errorf("Expected no active context")
+	}
+
+	contextCommand([]string{ "set", "@work", "+infra" })
+
+	if contextFilename() != filepath.Join(dir, "tasks-context.txt") || activeContext() != "@work +infra" {
+		t.Errorf(getMessage(contextFilename(), "context", "@work +infra", activeContext()))
+	}
+
+	tasks := todo.ParseAll("deploy @work +infra\ncall bob @work\n")
+	if matches := contextFilter().Indexes(tasks); fmt.Sprint(matches) != "[0]" {
+		t.Errorf(getMessage("@work +infra", "context filter", "[0]", matches))
+	}
+
+	contextCommand([]string{ "clear" })
+
+	if _, err := os.Stat(contextFilename()); !os.IsNotExist(err) || activeContext() != "" {
+		t.Errorf("Expected the context to be cleared")
+	}
+
+	if contents, err := ioutil.ReadFile(filename); err != nil || string(contents) != "call bob @work\n" {
+		t.Errorf("%s was modified: %q (%v)", filename, contents, err)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+/* Waiting for:
+ * Delegated tasks are in the waiting status with three keys:
+ *	waiting:PERSON			who the task is waiting for
+ *	waiting-since:DATE		when the task was delegated
+ *	followup:DATE			when to check on it, shown in the quick view from that day on
+ */
+
+// waitTask implements wait TASK [PERSON [FOLLOWUP]], where FOLLOWUP is a date in any format accepted by todo.ParseDates
+func waitTask(args []string, tasks Tasks) {
+	if len(args) < 1 || len(args) > 3 {
+		log.Fatalf("Usage: wait TASK [PERSON [FOLLOWUP]]")
+	}
+
+	followup := ""
+	if len(args) == 3 {
+		followup = strings.TrimPrefix(todo.ParseDates("followup:" + args[2]), "followup:")
+		if _, err := time.Parse("2006-01-02", followup); err != nil {
+			log.Fatalf("Invalid follow up date %s", args[2])
+		}
+	}
+
+	numbers := changeStatus(args[0], tasks, "waiting", "Waiting on the following task:")
+	task := &tasks[numbers[0]]
+
+	if len(args) >= 2 {
+		task.SetValue("waiting", args[1])
+		task.SetValue("waiting-since", time.Now().Format("2006-01-02"))
+	}
+
+	if followup != "" {
+		task.SetValue("followup", followup)
+	}
+
+	writeTasks(tasks)
+}
+
+// followupDate returns the follow up date of a task or the zero time if it doesn't have one
+func followupDate(task todo.Task) time.Time {
+	date, _ := time.Parse("2006-01-02", task.Value("followup"))
+	return date
+}
+
+// dueForFollowup returns the indexes of open tasks whose follow up date is today or earlier
+func dueForFollowup(tasks Tasks, n time.Time) []int {
+	today := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+
+	var numbers []int
+	for i, task := range tasks {
+		date := followupDate(task)
+		if !task.Completed && !date.IsZero() && !date.After(today) {
+			numbers = append(numbers, i)
+		}
+	}
+
+	return numbers
+}
+
+// printFollowups prints the section of the quick view listing tasks which should be followed up on
+func printFollowups(tasks Tasks, filter todo.Filter, n time.Time) {
+	var numbers []int
+	for _, i := range dueForFollowup(tasks, n) {
+		if filter(tasks[i]) {
+			numbers = append(numbers, i)
+		}
+	}
+
+	if len(numbers) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Follow up:")
+	for _, i := range numbers {
+		fmt.Printf("%03d %s\n", i + 1, tasks[i])
+	}
+}
+
+// daysWaiting returns the number of days since the task was delegated (or created, for tasks without waiting-since:) or -1 if it isn't known
+func daysWaiting(task todo.Task, n time.Time) int {
+	since, err := time.Parse("2006-01-02", task.Value("waiting-since"))
+	if err != nil {
+		since = task.CreationDate
+	}
+
+	if since.IsZero() {
+		return -1
+	}
+
+	today := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+	return int(today.Sub(since).Hours() / 24)
+}
+
+// printWaitingReport lists open tasks with a waiting: key grouped by person, longest waiting first
+func printWaitingReport(tasks Tasks, n time.Time) {
+	people := make(map[string][]int)
+	for i, task := range tasks {
+		if person := task.Value("waiting"); person != "" && !task.Completed {
+			people[person] = append(people[person], i)
+		}
+	}
+
+	if len(people) == 0 {
+		fmt.Println("Nothing is waiting on anyone")
+		return
+	}
+
+	var names []string
+	for name := range people {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		numbers := people[name]
+		sort.SliceStable(numbers, func(i, j int) bool {
+			return daysWaiting(tasks[numbers[i]], n) > daysWaiting(tasks[numbers[j]], n)
+		})
+
+		fmt.Printf("%s (%d)\n", name, len(numbers))
+		for _, i := range numbers {
+			waited := "unknown"
+			if days := daysWaiting(tasks[i], n); days == 1 {
+				waited = "1 day"
+			} else if days >= 0 {
+				waited = fmt.Sprintf("%d days", days)
+			}
+
+			followup := ""
+			if date := followupDate(tasks[i]); !date.IsZero() {
+				followup = ", follow up " + date.Format("2006-01-02")
+			}
+
+			// The keys are already summarized
+			shown := tasks[i]
+			for _, key := range []string{ "status", "waiting", "waiting-since", "followup" } {
+				shown.RemoveValue(key)
+			}
+
+			fmt.Printf("  %03d %s (%s%s)\n", i + 1, shown.Description, waited, followup)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestWaiting(t *testing.T) {
+	tasks := todo.ParseAll(`review PR waiting:alice waiting-since:2020-08-10 followup:2020-08-15
+2020-08-01 call back waiting:bob followup:2020-08-20
+x done waiting:alice followup:2020-08-01
+no follow up
+`)
+	n := time.Date(2020, 8, 15, 9, 0, 0, 0, time.UTC)
+
+	if due := fmt.Sprint(dueForFollowup(tasks, n)); due != "[0]" {
+		t.Errorf(getMessage("waiting", "follow ups", "[0]", due))
+	}
+
+	if days := daysWaiting(tasks[0], n); days != 5 {
+		t.Errorf(getMessage("waiting", "days since waiting-since", 5, days))
+	}
+
+	if days := daysWaiting(tasks[1], n); days != 14 {
+		t.Errorf(getMessage("waiting", "days since creation", 14, days))
+	}
+
+	if days := daysWaiting(tasks[3], n); days != -1 {
+		t.Errorf(getMessage("waiting", "unknown days", -1, days))
+	}
+}
+
+func TestParseFollowupDates(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+	if parsed := todo.ParseDates("call followup:tom due:tomorrow"); parsed != "call followup:" + tomorrow + " due:" + tomorrow {
+		t.Errorf("Unexpected dates in %s", parsed)
+	}
+}
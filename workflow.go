@@ -0,0 +1,147 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func cancelledFilename() string {
+	return sidecarFilename("cancelled")
+}
+
+// transitionTasks moves tasks to a new status, exiting without writing anything if any transition isn't allowed
+func transitionTasks(numbers []int, tasks Tasks, status string) {
+	w := workflow()
+	n := time.Now()
+
+	for _, i := range numbers {
+		if err := w.Transition(&tasks[i], status, n); err != nil {
+			log.Fatalf("Unable to change the status of task %d: %s", i + 1, err)
+		}
+	}
+}
+
+// changeStatus moves the tasks to a new status and backs up the original file, returning the indexes of the tasks.
+// Every transition is checked before anything is written.
+func changeStatus(input string, tasks Tasks, status string, msg string) []int {
+	_, numbers := numbersToTasks(input, tasks, "")
+	if len(numbers) == 0 {
+		log.Fatalf("You must provide at least one task number")
+	}
+
+	transitionTasks(numbers, tasks, status)
+	backupOriginal(backup)
+
+	log.Printf(msg)
+	for _, i := range numbers {
+		fmt.Printf("%03d %s\n", i + 1, tasks[i])
+	}
+
+	return numbers
+}
+
+// setStatus implements set-status TASK... STATUS
+func setStatus(args []string, tasks Tasks) {
+	if len(args) < 2 {
+		log.Fatalf("Usage: set-status TASK... STATUS (one of %s)", strings.Join(workflow().States, ", "))
+	}
+
+	status := args[len(args) - 1]
+	changeStatus(strings.Join(args[:len(args) - 1], " "), tasks, status, "Changed the status of the following tasks to " + status + ":")
+	writeTasks(tasks)
+}
+
+// cancelTasks moves tasks to the cancelled status
+func cancelTasks(input string, tasks Tasks) {
+	changeStatus(input, tasks, todo.Cancelled, "Cancelled the following tasks:")
+	writeTasks(tasks)
+}
+
+// archiveCancelled marks cancelled tasks as deleted and returns them appended to FILENAME-cancelled.txt, which must be
+// saved along with the archive
+func archiveCancelled(tasks Tasks) []todo.Sidecar {
+	var cancelled Tasks
+	for i, task := range tasks {
+		if task.Completed && !task.Deleted && task.Value("status") == todo.Cancelled {
+			cancelled = append(cancelled, task)
+			tasks[i].Deleted = true
+		}
+	}
+
+	if len(cancelled) == 0 {
+		return nil
+	}
+
+	existing, err := store.LoadSidecar("cancelled")
+	if err != nil {
+		log.Fatalf("Unable to load cancelled tasks: %s", err)
+	}
+
+	log.Printf("Archived the following cancelled tasks to %s:", cancelledFilename())
+	for _, task := range cancelled {
+		log.Printf("%s", task)
+	}
+
+	return []todo.Sidecar{ { Name: "cancelled", Tasks: append(existing, cancelled...) } }
+}
+
+// printKanban prints the tasks matched by the filter in one column per status
+func printKanban(tasks Tasks, filter todo.Filter) {
+	const width = 32
+
+	w := workflow()
+	columns := make(map[string][]string)
+	order := append([]string{}, w.States...)
+
+	for _, i := range filter.Indexes(tasks) {
+		status := w.Status(tasks[i])
+		if _, ok := columns[status]; !ok && !w.Valid(status) {
+			// Statuses missing from the workflow get a column after the known ones
+			order = append(order, status)
+		}
+
+		// The column already shows the status
+		description := tasks[i]
+		description.RemoveValue("status")
+
+		card := []rune(fmt.Sprintf("%03d %s", i + 1, description.Description))
+		if len(card) > width - 1 {
+			card = append(card[:width - 4], []rune("...")...)
+		}
+
+		columns[status] = append(columns[status], string(card))
+	}
+
+	rows := 0
+	var header, rule strings.Builder
+	for _, status := range order {
+		fmt.Fprintf(&header, "%-*s", width, fmt.Sprintf("%s (%d)", strings.ToUpper(status), len(columns[status])))
+		rule.WriteString(strings.Repeat("-", width - 1) + " ")
+
+		if len(columns[status]) > rows {
+			rows = len(columns[status])
+		}
+	}
+
+	fmt.Println(strings.TrimRight(header.String(), " "))
+	fmt.Println(strings.TrimRight(rule.String(), " "))
+
+	for row := 0; row < rows; row++ {
+		var line strings.Builder
+		for _, status := range order {
+			card := ""
+			if row < len(columns[status]) {
+				card = columns[status][row]
+			}
+			fmt.Fprintf(&line, "%-*s", width, card)
+		}
+		fmt.Println(strings.TrimRight(line.String(), " "))
+	}
+}
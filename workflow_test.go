@@ -0,0 +1,107 @@
+// Copyright 2020 Matt Montgomery
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ConfusedPolarBear/todotogo/pkg/todo"
+)
+
+func TestWorkflow(t *testing.T) {
+	w := todo.DefaultWorkflow
+	n := time.Date(2020, 8, 15, 12, 0, 0, 0, time.UTC)
+
+	task := todo.ParseTask("2020-08-01 write docs")
+	if status := w.Status(task); status != "todo" {
+		t.Errorf(getMessage("workflow", "initial status", "todo", status))
+	}
+
+	if status := w.Status(todo.ParseTask("x done without a status")); status != "done" {
+		t.Errorf(getMessage("workflow", "completed status", "done", status))
+	}
+
+	if err := w.Transition(&task, "doing", n); err != nil || task.String() != "2020-08-01 write docs status:doing" {
+		t.Errorf("Unexpected task %s after moving to doing (%v)", task, err)
+	}
+
+	if err := w.Transition(&task, todo.Cancelled, n); err != nil || task.String() != "x 2020-08-15 2020-08-01 write docs status:cancelled" {
+		t.Errorf("Unexpected task %s after cancelling (%v)", task, err)
+	}
+
+	if err := w.Transition(&task, "doing", n); err == nil {
+		t.Errorf("Moving a cancelled task to doing should fail")
+	}
+
+	if err := w.Transition(&task, "bogus", n); err == nil {
+		t.Errorf("Moving to an unknown status should fail")
+	}
+
+	if err := w.Transition(&task, "todo", n); err != nil || task.String() != "2020-08-01 write docs status:todo" {
+		t.Errorf("Unexpected task %s after reopening (%v)", task, err)
+	}
+}
+
+func TestCancelledStats(t *testing.T) {
+	tasks := todo.ParseAll(`x 2020-08-03 2020-08-01 on time due:2020-08-05
+x 2020-08-10 2020-08-01 cancelled late due:2020-08-05 status:cancelled
+`)
+
+	stats := todo.ComputeStats(tasks, time.Date(2020, 8, 15, 12, 0, 0, 0, time.UTC))
+
+	if stats.Total != 2 || stats.Completed != 1 || stats.Cancelled != 1 || stats.OnTimeRate != 1 {
+		t.Errorf("Unexpected stats: %d total, %d completed, %d cancelled, %v on time", stats.Total, stats.Completed, stats.Cancelled, stats.OnTimeRate)
+	}
+}
+
+func TestArchiveCancelled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todotogo")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Cancelled tasks are kept next to the task file even if it doesn't end in .txt
+	saveGlobals(t)
+	filename, backup, unlockStore = filepath.Join(dir, "tasks"), false, nil
+	openStore()
+
+	if err := ioutil.WriteFile(filename, []byte("x finished\nx dropped status:cancelled\nopen\n"), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", filename, err)
+	}
+
+	// Cancelled tasks are written along with the archive so hooks see every file
+	writes := 0
+	store.(*todo.FileStore).BeforeWrite = func(w []todo.FileWrite) error {
+		writes++
+		if len(w) != 3 {
+			t.Errorf(getMessage("archive", "files in a single write", 3, len(w)))
+		}
+		return nil
+	}
+
+	tasks, _ := store.Load()
+	archiveTasks(tasks)
+	unlockStore()
+
+	if writes != 1 {
+		t.Errorf(getMessage("archive", "writes", 1, writes))
+	}
+
+	expected := map[string]string {
+		"tasks": "open\n",
+		"tasks-done.txt": "x finished\n",
+		"tasks-cancelled.txt": "x dropped status:cancelled\n",
+	}
+
+	for name, contents := range expected {
+		if actual, _ := ioutil.ReadFile(filepath.Join(dir, name)); string(actual) != contents {
+			t.Errorf(getMessage(name, "contents after archiving", contents, string(actual)))
+		}
+	}
+}